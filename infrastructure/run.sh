@@ -9,6 +9,8 @@ export "$(grep -v '^#' ../../.env | xargs)"
 
 export TF_VAR_git_sha=$(git rev-parse --short HEAD)
 export TF_VAR_aws_profile="$AWS_PROFILE"
+export TF_VAR_twilio_account_sid="$TWILIO_ACCOUNT_SID"
+export TF_VAR_twilio_from_number="$TWILIO_FROM_NUMBER"
 
 terraform init -backend-config=backend.hcl
 terraform plan -out=plan.out
@@ -49,3 +49,81 @@ resource "aws_ecr_lifecycle_policy" "text_agent_task_tracking" {
   }
 EOF
 }
+
+resource "aws_ecr_repository" "text_agent_twilio_webhook" {
+  name = "text-agent-twilio-webhook"
+}
+
+resource "aws_ecr_lifecycle_policy" "text_agent_twilio_webhook" {
+  repository = aws_ecr_repository.text_agent_twilio_webhook.name
+  policy     = <<EOF
+  {
+    "rules": [
+      {
+        "rulePriority": 1,
+        "description": "Expire older images.",
+        "selection": {
+          "tagStatus": "any",
+          "countType": "imageCountMoreThan",
+          "countNumber": 1
+        },
+        "action": {
+          "type": "expire"
+        }
+      }
+    ]
+  }
+EOF
+}
+
+resource "aws_ecr_repository" "text_agent_worker" {
+  name = "text-agent-worker"
+}
+
+resource "aws_ecr_lifecycle_policy" "text_agent_worker" {
+  repository = aws_ecr_repository.text_agent_worker.name
+  policy     = <<EOF
+  {
+    "rules": [
+      {
+        "rulePriority": 1,
+        "description": "Expire older images.",
+        "selection": {
+          "tagStatus": "any",
+          "countType": "imageCountMoreThan",
+          "countNumber": 1
+        },
+        "action": {
+          "type": "expire"
+        }
+      }
+    ]
+  }
+EOF
+}
+
+resource "aws_ecr_repository" "text_agent_scheduler" {
+  name = "text-agent-scheduler"
+}
+
+resource "aws_ecr_lifecycle_policy" "text_agent_scheduler" {
+  repository = aws_ecr_repository.text_agent_scheduler.name
+  policy     = <<EOF
+  {
+    "rules": [
+      {
+        "rulePriority": 1,
+        "description": "Expire older images.",
+        "selection": {
+          "tagStatus": "any",
+          "countType": "imageCountMoreThan",
+          "countNumber": 1
+        },
+        "action": {
+          "type": "expire"
+        }
+      }
+    ]
+  }
+EOF
+}
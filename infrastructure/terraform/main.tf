@@ -20,3 +20,24 @@ variable "aws_profile" {
 variable "git_sha" {
   type = string
 }
+
+variable "twilio_account_sid" {
+  type = string
+}
+
+variable "twilio_from_number" {
+  type        = string
+  description = "The Twilio number we text from, in E164 format"
+}
+
+variable "default_timezone" {
+  type        = string
+  default     = "UTC"
+  description = "The IANA timezone due dates are resolved in for conversations where no one has set theirs, e.g. America/Denver"
+}
+
+variable "agent_return_control" {
+  type        = bool
+  default     = false
+  description = "Have the agent return control to the messaging service, which runs the action groups in-process, instead of invoking their Lambdas"
+}
@@ -32,7 +32,7 @@ resource "aws_bedrockagent_agent_action_group" "messaging" {
 
       functions {
         name        = "messaging_list_recent"
-        description = "Use this function to get the list of recent messages for a conversation."
+        description = "Use this function to get the list of recent messages for a conversation. If a message has failed_deliveries, it didn't reach those numbers; consider re-sending it or letting the group know. Participants listed in opted_out texted STOP and won't receive anything you send."
         parameters {
           map_block_key = "conversation_phone_numbers"
           type          = "array"
@@ -40,11 +40,185 @@ resource "aws_bedrockagent_agent_action_group" "messaging" {
           required      = true
         }
       }
+
+      functions {
+        name        = "messaging_list_range"
+        description = "Use this function to look further back than the recent messages, e.g. to answer \"what did we decide last Tuesday?\". Messages are returned oldest first, a page at a time."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "after"
+          type          = "string"
+          description   = "Only include messages sent at or after this time; an ISO 8601 date (e.g. 2025-06-30, UTC) or timestamp"
+          required      = false
+        }
+        parameters {
+          map_block_key = "before"
+          type          = "string"
+          description   = "Only include messages sent before this time; an ISO 8601 date (e.g. 2025-07-01, UTC) or timestamp"
+          required      = false
+        }
+        parameters {
+          map_block_key = "cursor"
+          type          = "string"
+          description   = "The next_cursor from a previous call, to get the next page"
+          required      = false
+        }
+        parameters {
+          map_block_key = "newest_first"
+          type          = "boolean"
+          description   = "Set to true to page backwards from the newest message"
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "messaging_search"
+        description = "Use this function to search the whole conversation history by keyword, e.g. to answer \"when did Joe say he'd get the laptop?\". Results are ranked best match first."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "query"
+          type          = "string"
+          description   = "The keywords to search for, e.g. \"joe laptop\""
+          required      = true
+        }
+        parameters {
+          map_block_key = "limit"
+          type          = "integer"
+          description   = "The maximum number of results to return; defaults to 10"
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "participants_list"
+        description = "Use this function to get the people in a conversation: their phone numbers and, if known, their names, aliases and timezones."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+      }
+
+      functions {
+        name        = "participants_set_name"
+        description = "Use this function to record who a phone number belongs to, e.g. when someone introduces themselves or is addressed by name. Only the values you pass are changed."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "phone_number"
+          type          = "string"
+          description   = "The phone number of the participant"
+          required      = true
+        }
+        parameters {
+          map_block_key = "display_name"
+          type          = "string"
+          description   = "The name to use for the participant"
+          required      = false
+        }
+        parameters {
+          map_block_key = "aliases"
+          type          = "array"
+          description   = "Other names the participant goes by, e.g. nicknames; replaces any existing aliases"
+          required      = false
+        }
+        parameters {
+          map_block_key = "timezone"
+          type          = "string"
+          description   = "The participant's IANA timezone, e.g. America/Denver"
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "reminders_get_policy"
+        description = "Use this function to see how the conversation is reminded about tasks that are due soon or overdue."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+      }
+
+      functions {
+        name        = "reminders_set_policy"
+        description = "Use this function to change how the conversation is reminded about tasks that are due, e.g. when someone asks for no texts after 9pm or to stop the reminders. Only the values you pass are changed."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "lead_time"
+          type          = "string"
+          description   = "How long before the due date to send a reminder, e.g. 24h; at most 168h"
+          required      = false
+        }
+        parameters {
+          map_block_key = "repeat_every"
+          type          = "string"
+          description   = "How often to remind about an overdue task again, e.g. 24h, up to 168h; 0 reminds just once"
+          required      = false
+        }
+        parameters {
+          map_block_key = "quiet_hours"
+          type          = "string"
+          description   = "When not to send reminders, in the conversation's local time, e.g. 21:00-08:00; none to send them any time"
+          required      = false
+        }
+        parameters {
+          map_block_key = "paused"
+          type          = "boolean"
+          description   = "true to stop sending reminders, false to start again"
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "messaging_get_attachment"
+        description = "Use this function to get a file that was attached to a message, including its description and a link to download it."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "message_id"
+          type          = "string"
+          description   = "The ID of the message the file was attached to"
+          required      = true
+        }
+        parameters {
+          map_block_key = "attachment_id"
+          type          = "string"
+          description   = "The ID of the attachment"
+          required      = true
+        }
+      }
     }
   }
 
   action_group_executor {
-    lambda = aws_lambda_function.messaging.arn
+    lambda         = var.agent_return_control ? null : aws_lambda_function.messaging.arn
+    custom_control = var.agent_return_control ? "RETURN_CONTROL" : null
   }
 
   depends_on = [
@@ -36,3 +36,231 @@ resource "aws_dynamodb_table" "messaging" {
     Service = "TextAgent"
   }
 }
+
+resource "aws_dynamodb_table" "participants" {
+  name         = "text-agent-participants"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+  range_key    = "phone_number"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "phone_number"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-participants"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "opt_outs" {
+  name         = "text-agent-opt-outs"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "phone_number"
+
+  attribute {
+    name = "phone_number"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-opt-outs"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "agent_sessions" {
+  name         = "text-agent-agent-sessions"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-agent-sessions"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "agent_invocations" {
+  name         = "text-agent-agent-invocations"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "id"
+
+  attribute {
+    name = "id"
+    type = "S"
+  }
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "started_at"
+    type = "N"
+  }
+
+  global_secondary_index {
+    name            = "ConversationIdIndex"
+    hash_key        = "conversation_id"
+    range_key       = "started_at"
+    projection_type = "ALL"
+  }
+
+  tags = {
+    Name    = "text-agent-agent-invocations"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "agent_trace_events" {
+  name         = "text-agent-agent-trace-events"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "invocation_id"
+  range_key    = "sequence"
+
+  attribute {
+    name = "invocation_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "sequence"
+    type = "N"
+  }
+
+  tags = {
+    Name    = "text-agent-agent-trace-events"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "usage" {
+  name         = "text-agent-usage"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+  range_key    = "day_model"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "day_model"
+    type = "S"
+  }
+
+  attribute {
+    name = "day"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name            = "DayIndex"
+    hash_key        = "day"
+    range_key       = "conversation_id"
+    projection_type = "ALL"
+  }
+
+  tags = {
+    Name    = "text-agent-usage"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "rate_limits" {
+  name         = "text-agent-rate-limits"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-rate-limits"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "pending_runs" {
+  name         = "text-agent-pending-runs"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-pending-runs"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "conversation_locks" {
+  name         = "text-agent-conversation-locks"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name    = "text-agent-conversation-locks"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "reminders" {
+  name         = "text-agent-reminders"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "task_id"
+
+  attribute {
+    name = "task_id"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-reminders"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_dynamodb_table" "reminder_policies" {
+  name         = "text-agent-reminder-policies"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "conversation_id"
+
+  attribute {
+    name = "conversation_id"
+    type = "S"
+  }
+
+  tags = {
+    Name    = "text-agent-reminder-policies"
+    Service = "TextAgent"
+  }
+}
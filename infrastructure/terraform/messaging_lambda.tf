@@ -15,8 +15,13 @@ resource "aws_lambda_function" "messaging" {
 
   environment {
     variables = {
-      AGENT_ALIAS_ID_SECRET_ID = aws_secretsmanager_secret.bedrock_agent_alias_id.id
-      AGENT_ID_SECRET_ID       = aws_secretsmanager_secret.bedrock_agent_id.id
+      ATTACHMENTS_BUCKET          = aws_s3_bucket.attachments.bucket
+      JOB_QUEUE_URL               = aws_sqs_queue.jobs.url
+      MEDIA_DESCRIBER_MODEL_ID    = "us.amazon.nova-lite-v1:0"
+      TWILIO_ACCOUNT_SID          = var.twilio_account_sid
+      TWILIO_AUTH_TOKEN_SECRET_ID = aws_secretsmanager_secret.twilio_auth_token.id
+      TWILIO_FROM_NUMBER          = var.twilio_from_number
+      TWILIO_STATUS_CALLBACK_URL  = "${aws_lambda_function_url.twilio_webhook.function_url}status"
     }
   }
 
@@ -53,7 +58,7 @@ resource "aws_iam_role_policy" "lambda_exec_policy_messaging" {
       {
         Effect = "Allow"
         Action = [
-          "bedrock:InvokeAgent"
+          "bedrock:InvokeModel"
         ]
         Resource = [
           "*"
@@ -83,7 +88,30 @@ resource "aws_iam_role_policy" "lambda_exec_policy_messaging" {
         ]
         Resource = [
           aws_dynamodb_table.messaging.arn,
-          "${aws_dynamodb_table.messaging.arn}/index/*"
+          "${aws_dynamodb_table.messaging.arn}/index/*",
+          aws_dynamodb_table.participants.arn,
+          aws_dynamodb_table.opt_outs.arn,
+          aws_dynamodb_table.pending_runs.arn,
+          aws_dynamodb_table.reminder_policies.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:GetObject",
+          "s3:PutObject"
+        ]
+        Resource = [
+          "${aws_s3_bucket.attachments.arn}/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "sqs:SendMessage"
+        ]
+        Resource = [
+          aws_sqs_queue.jobs.arn
         ]
       },
       {
@@ -92,8 +120,7 @@ resource "aws_iam_role_policy" "lambda_exec_policy_messaging" {
           "secretsmanager:GetSecretValue"
         ]
         Resource = [
-          aws_secretsmanager_secret.bedrock_agent_alias_id.arn,
-          aws_secretsmanager_secret.bedrock_agent_id.arn,
+          aws_secretsmanager_secret.twilio_auth_token.arn,
         ]
       }
     ]
@@ -0,0 +1,17 @@
+resource "aws_s3_bucket" "attachments" {
+  bucket_prefix = "text-agent-attachments-"
+
+  tags = {
+    Name    = "text-agent-attachments"
+    Service = "TextAgent"
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "attachments" {
+  bucket = aws_s3_bucket.attachments.id
+
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
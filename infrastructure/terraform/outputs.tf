@@ -11,3 +11,8 @@ output "aws_region" {
   description = "The AWS region"
   value       = local.region
 }
+
+output "twilio_webhook_url" {
+  description = "The URL to configure as the Twilio messaging webhook"
+  value       = aws_lambda_function_url.twilio_webhook.function_url
+}
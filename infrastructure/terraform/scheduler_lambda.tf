@@ -0,0 +1,119 @@
+# CloudWatch Log Group with retention period
+resource "aws_cloudwatch_log_group" "scheduler" {
+  name              = "/aws/lambda/text-agent-scheduler"
+  retention_in_days = 14
+}
+
+resource "aws_lambda_function" "scheduler" {
+  function_name = "text-agent-scheduler"
+  role          = aws_iam_role.lambda_exec_scheduler.arn
+  package_type  = "Image"
+  image_uri     = "${aws_ecr_repository.text_agent_scheduler.repository_url}:${var.git_sha}"
+  memory_size   = 128
+  timeout       = 300
+  architectures = ["arm64"]
+
+  environment {
+    variables = {
+      DEFAULT_TIMEZONE            = var.default_timezone
+      TWILIO_ACCOUNT_SID          = var.twilio_account_sid
+      TWILIO_AUTH_TOKEN_SECRET_ID = aws_secretsmanager_secret.twilio_auth_token.id
+      TWILIO_FROM_NUMBER          = var.twilio_from_number
+      TWILIO_STATUS_CALLBACK_URL  = "${aws_lambda_function_url.twilio_webhook.function_url}status"
+    }
+  }
+
+  depends_on = [
+    aws_iam_role_policy.lambda_exec_policy_scheduler,
+    aws_cloudwatch_log_group.scheduler,
+  ]
+}
+
+# Reminders held for quiet hours go out on the first run after they end, so this is about how late they can be.
+resource "aws_cloudwatch_event_rule" "scheduler" {
+  name                = "text-agent-scheduler"
+  schedule_expression = "rate(15 minutes)"
+}
+
+resource "aws_cloudwatch_event_target" "scheduler" {
+  rule = aws_cloudwatch_event_rule.scheduler.name
+  arn  = aws_lambda_function.scheduler.arn
+}
+
+resource "aws_lambda_permission" "allow_eventbridge_scheduler" {
+  statement_id  = "AllowEventBridgeToInvokeLambda"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.scheduler.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.scheduler.arn
+}
+
+resource "aws_iam_role" "lambda_exec_scheduler" {
+  name = "text-agent-scheduler-exec-role"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_iam_role_policy" "lambda_exec_policy_scheduler" {
+  name = "text-agent-scheduler-exec-policy"
+  role = aws_iam_role.lambda_exec_scheduler.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "logs:CreateLogGroup",
+          "logs:CreateLogStream",
+          "logs:PutLogEvents",
+        ]
+        Resource = "arn:aws:logs:*:*:*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:*",
+        ]
+        Resource = [
+          aws_dynamodb_table.messaging.arn,
+          "${aws_dynamodb_table.messaging.arn}/index/*",
+          aws_dynamodb_table.participants.arn,
+          aws_dynamodb_table.opt_outs.arn,
+          aws_dynamodb_table.reminders.arn,
+          aws_dynamodb_table.reminder_policies.arn,
+          aws_dynamodb_table.task_tracking.arn,
+          "${aws_dynamodb_table.task_tracking.arn}/index/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "secretsmanager:GetSecretValue"
+        ]
+        Resource = [
+          aws_secretsmanager_secret.twilio_auth_token.arn,
+        ]
+      }
+    ]
+  })
+}
@@ -15,3 +15,8 @@ resource "aws_secretsmanager_secret_version" "bedrock_agent_id" {
   secret_id     = aws_secretsmanager_secret.bedrock_agent_id.id
   secret_string = aws_bedrockagent_agent.text_agent.agent_id
 }
+
+# The value is set manually from the Twilio console.
+resource "aws_secretsmanager_secret" "twilio_auth_token" {
+  name = "text-agent-twilio-auth-token"
+}
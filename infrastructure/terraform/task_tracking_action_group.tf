@@ -34,11 +34,163 @@ resource "aws_bedrockagent_agent_action_group" "task_tracking" {
           description   = "The text of the message that triggered the task creation"
           required      = true
         }
+        parameters {
+          map_block_key = "idempotency_key"
+          type          = "string"
+          description   = "Optional. Calls with the same key within a few minutes return the task that was already created instead of creating a duplicate."
+          required      = false
+        }
+        parameters {
+          map_block_key = "assignee"
+          type          = "string"
+          description   = "The phone number of the participant who owns the task, e.g. whoever said they'd do it. Must be in the conversation."
+          required      = false
+        }
+        parameters {
+          map_block_key = "due"
+          type          = "string"
+          description   = "When the task is due, either a date like 2025-08-23 or the phrase that was used, e.g. \"by Friday\" or \"before the 8/23 campout\"."
+          required      = false
+        }
+        parameters {
+          map_block_key = "source_message_id"
+          type          = "string"
+          description   = "The ID of the message the due date was mentioned in; relative dates like \"Friday\" are worked out from when it was sent. Defaults to now."
+          required      = false
+        }
+        parameters {
+          map_block_key = "source_sent_at"
+          type          = "string"
+          description   = "When the message the due date was mentioned in was sent, its sent_at_utc, e.g. 2025-08-22T15:04:05Z. Pass it along with source_message_id."
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "task_tracking_update"
+        description = "Use this function to change an existing task, e.g. to fix a typo or refine its description. Only the fields you pass are changed; don't delete and re-create a task to edit it."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "task_id"
+          type          = "string"
+          description   = "The ID of the task to update"
+          required      = true
+        }
+        parameters {
+          map_block_key = "name"
+          type          = "string"
+          description   = "The new name of the task"
+          required      = false
+        }
+        parameters {
+          map_block_key = "description"
+          type          = "string"
+          description   = "The new description of the task"
+          required      = false
+        }
+        parameters {
+          map_block_key = "source"
+          type          = "string"
+          description   = "The new source of the task"
+          required      = false
+        }
+        parameters {
+          map_block_key = "assignee"
+          type          = "string"
+          description   = "The phone number of the participant who now owns the task; pass an empty value to unassign it. Must be in the conversation."
+          required      = false
+        }
+        parameters {
+          map_block_key = "due"
+          type          = "string"
+          description   = "When the task is now due, either a date like 2025-08-23 or the phrase that was used, e.g. \"by Friday\"; pass an empty value to remove the due date."
+          required      = false
+        }
+        parameters {
+          map_block_key = "source_message_id"
+          type          = "string"
+          description   = "The ID of the message the new due date was mentioned in; relative dates are worked out from when it was sent. Defaults to now."
+          required      = false
+        }
+        parameters {
+          map_block_key = "source_sent_at"
+          type          = "string"
+          description   = "When the message the new due date was mentioned in was sent, its sent_at_utc, e.g. 2025-08-22T15:04:05Z. Pass it along with source_message_id."
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "task_tracking_list_by_assignee"
+        description = "Use this function to get the tasks assigned to someone across all of their conversations, e.g. to answer \"what's on my plate?\". Tasks from this conversation are listed in full; tasks from their other conversations only have their name, status and dates, and their details shouldn't be guessed at."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "assignee"
+          type          = "string"
+          description   = "The phone number of the participant; they have to be in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "status"
+          type          = "string"
+          description   = "Only list tasks with this status: open, completed, canceled or all. Defaults to open."
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "task_tracking_complete"
+        description = "Use this function to mark a task as completed, e.g. when someone says they've done it. Canceled tasks can't be completed."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "task_id"
+          type          = "string"
+          description   = "The ID of the task to complete"
+          required      = true
+        }
+        parameters {
+          map_block_key = "completed_on"
+          type          = "string"
+          description   = "The date the task was completed on, like 2025-08-23. Defaults to today."
+          required      = false
+        }
+      }
+
+      functions {
+        name        = "task_tracking_cancel"
+        description = "Use this function to cancel a task that's no longer needed. Completed tasks can't be canceled."
+        parameters {
+          map_block_key = "conversation_phone_numbers"
+          type          = "array"
+          description   = "The phone numbers involved in the conversation"
+          required      = true
+        }
+        parameters {
+          map_block_key = "task_id"
+          type          = "string"
+          description   = "The ID of the task to cancel"
+          required      = true
+        }
       }
 
       functions {
         name        = "task_tracking_delete"
-        description = "Use this function to delete a task. A task should be deleted when it is completed or no longer needed."
+        description = "Use this function to delete a task that was created by mistake. Complete or cancel tasks instead so their history is kept."
         parameters {
           map_block_key = "task_id"
           type          = "string"
@@ -49,19 +201,26 @@ resource "aws_bedrockagent_agent_action_group" "task_tracking" {
 
       functions {
         name        = "task_tracking_list"
-        description = "Use this function to get the list of tasks for a conversation."
+        description = "Use this function to get the list of tasks for a conversation. Completed tasks include the date they were completed on, e.g. to answer \"what did we finish this month?\"."
         parameters {
           map_block_key = "conversation_phone_numbers"
           type          = "array"
           description   = "The phone numbers involved in the conversation"
           required      = true
         }
+        parameters {
+          map_block_key = "status"
+          type          = "string"
+          description   = "Only list tasks with this status: open, completed, canceled or all. Defaults to open."
+          required      = false
+        }
       }
     }
   }
 
   action_group_executor {
-    lambda = aws_lambda_function.task_tracking.arn
+    lambda         = var.agent_return_control ? null : aws_lambda_function.task_tracking.arn
+    custom_control = var.agent_return_control ? "RETURN_CONTROL" : null
   }
 
   depends_on = [
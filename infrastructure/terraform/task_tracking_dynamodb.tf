@@ -13,14 +13,65 @@ resource "aws_dynamodb_table" "task_tracking" {
     type = "S"
   }
 
+  attribute {
+    name = "assignee"
+    type = "S"
+  }
+
+  attribute {
+    name = "status"
+    type = "S"
+  }
+
+  attribute {
+    name = "due_on"
+    type = "S"
+  }
+
   global_secondary_index {
     name            = "ConversationIdIndex"
     hash_key        = "conversation_id"
     projection_type = "ALL"
   }
+
+  # Sparse; only assigned tasks are in it.
+  global_secondary_index {
+    name            = "AssigneeIndex"
+    hash_key        = "assignee"
+    projection_type = "ALL"
+  }
+
+  # Sparse; only tasks with a due date are in it. The reminder scheduler looks up the open ones due soon.
+  global_secondary_index {
+    name            = "StatusDueOnIndex"
+    hash_key        = "status"
+    range_key       = "due_on"
+    projection_type = "ALL"
+  }
   
   tags = {
     Name    = "text-agent-task-tracking"
     Service = "TextAgent"
   }
 }
+
+resource "aws_dynamodb_table" "task_idempotency" {
+  name         = "text-agent-task-idempotency"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "idempotency_key"
+
+  attribute {
+    name = "idempotency_key"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name    = "text-agent-task-idempotency"
+    Service = "TextAgent"
+  }
+}
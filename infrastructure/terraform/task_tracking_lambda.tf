@@ -14,7 +14,9 @@ resource "aws_lambda_function" "task_tracking" {
   architectures = ["arm64"]
 
   environment {
-    variables = {}
+    variables = {
+      DEFAULT_TIMEZONE = var.default_timezone
+    }
   }
 
   depends_on = [
@@ -71,7 +73,8 @@ resource "aws_iam_role_policy" "lambda_exec_policy" {
         ]
         Resource = [
           aws_dynamodb_table.task_tracking.arn,
-          "${aws_dynamodb_table.task_tracking.arn}/index/*"
+          "${aws_dynamodb_table.task_tracking.arn}/index/*",
+          aws_dynamodb_table.task_idempotency.arn
         ]
       }
     ]
@@ -0,0 +1,122 @@
+# CloudWatch Log Group with retention period
+resource "aws_cloudwatch_log_group" "twilio_webhook" {
+  name              = "/aws/lambda/text-agent-twilio-webhook"
+  retention_in_days = 14
+}
+
+resource "aws_lambda_function" "twilio_webhook" {
+  function_name = "text-agent-twilio-webhook"
+  role          = aws_iam_role.lambda_exec_twilio_webhook.arn
+  package_type  = "Image"
+  image_uri     = "${aws_ecr_repository.text_agent_twilio_webhook.repository_url}:${var.git_sha}"
+  memory_size   = 128
+  timeout       = 300
+  architectures = ["arm64"]
+
+  environment {
+    variables = {
+      ATTACHMENTS_BUCKET          = aws_s3_bucket.attachments.bucket
+      JOB_QUEUE_URL               = aws_sqs_queue.jobs.url
+      MEDIA_DESCRIBER_MODEL_ID    = "us.amazon.nova-lite-v1:0"
+      TWILIO_AUTH_TOKEN_SECRET_ID = aws_secretsmanager_secret.twilio_auth_token.id
+    }
+  }
+
+  depends_on = [
+    aws_iam_role_policy.lambda_exec_policy_twilio_webhook,
+    aws_cloudwatch_log_group.twilio_webhook,
+  ]
+}
+
+# Configure this URL (plus `?participants=...` for group conversations) as the Twilio number's messaging webhook.
+resource "aws_lambda_function_url" "twilio_webhook" {
+  function_name      = aws_lambda_function.twilio_webhook.function_name
+  authorization_type = "NONE" # Requests are authenticated with the X-Twilio-Signature header.
+}
+
+resource "aws_iam_role" "lambda_exec_twilio_webhook" {
+  name = "text-agent-twilio-webhook-exec-role"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_iam_role_policy" "lambda_exec_policy_twilio_webhook" {
+  name = "text-agent-twilio-webhook-exec-policy"
+  role = aws_iam_role.lambda_exec_twilio_webhook.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "bedrock:InvokeModel"
+        ]
+        Resource = [
+          "*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "logs:CreateLogGroup",
+          "logs:CreateLogStream",
+          "logs:PutLogEvents",
+        ]
+        Resource = "arn:aws:logs:*:*:*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:*",
+        ]
+        Resource = [
+          aws_dynamodb_table.messaging.arn,
+          "${aws_dynamodb_table.messaging.arn}/index/*",
+          aws_dynamodb_table.opt_outs.arn,
+          aws_dynamodb_table.participants.arn,
+          aws_dynamodb_table.pending_runs.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:GetObject",
+          "s3:PutObject"
+        ]
+        Resource = [
+          "${aws_s3_bucket.attachments.arn}/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "sqs:SendMessage"
+        ]
+        Resource = [
+          aws_sqs_queue.jobs.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "secretsmanager:GetSecretValue"
+        ]
+        Resource = [
+          aws_secretsmanager_secret.twilio_auth_token.arn,
+        ]
+      }
+    ]
+  })
+}
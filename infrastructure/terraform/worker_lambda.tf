@@ -0,0 +1,176 @@
+# Jobs that fail this many times go to the dead-letter queue; it matches job_queue.MaxAttempts.
+locals {
+  job_max_attempts = 5
+}
+
+resource "aws_sqs_queue" "jobs_dead_letter" {
+  name                      = "text-agent-jobs-dead-letter"
+  message_retention_seconds = 1209600 # 14 days, to have time to look into them.
+}
+
+resource "aws_sqs_queue" "jobs" {
+  name = "text-agent-jobs"
+  # Has to be at least the worker's timeout, or a job still being run is handed out again.
+  visibility_timeout_seconds = 360
+
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.jobs_dead_letter.arn
+    maxReceiveCount     = local.job_max_attempts
+  })
+}
+
+# CloudWatch Log Group with retention period
+resource "aws_cloudwatch_log_group" "worker" {
+  name              = "/aws/lambda/text-agent-worker"
+  retention_in_days = 14
+}
+
+resource "aws_lambda_function" "worker" {
+  function_name = "text-agent-worker"
+  role          = aws_iam_role.lambda_exec_worker.arn
+  package_type  = "Image"
+  image_uri     = "${aws_ecr_repository.text_agent_worker.repository_url}:${var.git_sha}"
+  memory_size   = 128
+  timeout       = 300
+  architectures = ["arm64"]
+
+  environment {
+    variables = {
+      AGENT_ALIAS_ID_SECRET_ID    = aws_secretsmanager_secret.bedrock_agent_alias_id.id
+      ATTACHMENTS_BUCKET          = aws_s3_bucket.attachments.bucket
+      DEFAULT_TIMEZONE            = var.default_timezone
+      JOB_QUEUE_URL               = aws_sqs_queue.jobs.url
+      MEDIA_DESCRIBER_MODEL_ID    = "us.amazon.nova-lite-v1:0"
+      AGENT_ID_SECRET_ID          = aws_secretsmanager_secret.bedrock_agent_id.id
+      TWILIO_ACCOUNT_SID          = var.twilio_account_sid
+      TWILIO_AUTH_TOKEN_SECRET_ID = aws_secretsmanager_secret.twilio_auth_token.id
+      TWILIO_FROM_NUMBER          = var.twilio_from_number
+      TWILIO_STATUS_CALLBACK_URL  = "${aws_lambda_function_url.twilio_webhook.function_url}status"
+    }
+  }
+
+  depends_on = [
+    aws_iam_role_policy.lambda_exec_policy_worker,
+    aws_cloudwatch_log_group.worker,
+  ]
+}
+
+# One job at a time; an agent run can take most of the function's timeout.
+resource "aws_lambda_event_source_mapping" "worker_jobs" {
+  event_source_arn        = aws_sqs_queue.jobs.arn
+  function_name           = aws_lambda_function.worker.arn
+  batch_size              = 1
+  function_response_types = ["ReportBatchItemFailures"]
+}
+
+resource "aws_iam_role" "lambda_exec_worker" {
+  name = "text-agent-worker-exec-role"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_iam_role_policy" "lambda_exec_policy_worker" {
+  name = "text-agent-worker-exec-policy"
+  role = aws_iam_role.lambda_exec_worker.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "bedrock:InvokeAgent",
+          "bedrock:InvokeModel"
+        ]
+        Resource = [
+          "*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "logs:CreateLogGroup",
+          "logs:CreateLogStream",
+          "logs:PutLogEvents",
+        ]
+        Resource = "arn:aws:logs:*:*:*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:*",
+        ]
+        Resource = [
+          aws_dynamodb_table.messaging.arn,
+          "${aws_dynamodb_table.messaging.arn}/index/*",
+          aws_dynamodb_table.participants.arn,
+          aws_dynamodb_table.opt_outs.arn,
+          aws_dynamodb_table.agent_sessions.arn,
+          aws_dynamodb_table.agent_invocations.arn,
+          aws_dynamodb_table.agent_trace_events.arn,
+          aws_dynamodb_table.usage.arn,
+          aws_dynamodb_table.rate_limits.arn,
+          aws_dynamodb_table.pending_runs.arn,
+          aws_dynamodb_table.conversation_locks.arn,
+          aws_dynamodb_table.reminder_policies.arn,
+          # Action groups can be run in-process when the agent returns control.
+          aws_dynamodb_table.task_tracking.arn,
+          "${aws_dynamodb_table.task_tracking.arn}/index/*",
+          aws_dynamodb_table.task_idempotency.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "s3:GetObject",
+          "s3:PutObject"
+        ]
+        Resource = [
+          "${aws_s3_bucket.attachments.arn}/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "sqs:SendMessage",
+          "sqs:ReceiveMessage",
+          "sqs:DeleteMessage",
+          "sqs:GetQueueAttributes"
+        ]
+        Resource = [
+          aws_sqs_queue.jobs.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "secretsmanager:GetSecretValue"
+        ]
+        Resource = [
+          aws_secretsmanager_secret.bedrock_agent_alias_id.arn,
+          aws_secretsmanager_secret.bedrock_agent_id.arn,
+          aws_secretsmanager_secret.twilio_auth_token.arn,
+        ]
+      }
+    ]
+  })
+}
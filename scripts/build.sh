@@ -24,19 +24,73 @@ GIT_COMMIT=$(git rev-parse --short HEAD)
 # Messaging
 ###
 
-cd services/messaging
+cd services
 REPO_NAME="text-agent-messaging"
 ECR_REPO="${AWS_ACCOUNT_ID}.dkr.ecr.${AWS_REGION}.amazonaws.com/${REPO_NAME}"
 aws ecr get-login-password --region "${AWS_REGION}" | docker login --username AWS --password-stdin "${ECR_REPO}"
 DOCKER_BUILDKIT=1 docker build \
   -t "${ECR_REPO}":"${GIT_COMMIT}" \
   -t "${ECR_REPO}":latest \
-  -f cmd/Dockerfile \
+  -f messaging/cmd/Dockerfile \
   .
 docker push "${ECR_REPO}":"${GIT_COMMIT}"
 docker push "${ECR_REPO}":latest
 echo "Successfully built and pushed Docker image to ${ECR_REPO}:${GIT_COMMIT} and ${ECR_REPO}:latest"
-cd ../../
+cd ../
+
+###
+# Twilio Webhook
+###
+
+cd services
+REPO_NAME="text-agent-twilio-webhook"
+ECR_REPO="${AWS_ACCOUNT_ID}.dkr.ecr.${AWS_REGION}.amazonaws.com/${REPO_NAME}"
+aws ecr get-login-password --region "${AWS_REGION}" | docker login --username AWS --password-stdin "${ECR_REPO}"
+DOCKER_BUILDKIT=1 docker build \
+  -t "${ECR_REPO}":"${GIT_COMMIT}" \
+  -t "${ECR_REPO}":latest \
+  -f messaging/cmd/twilio_webhook/Dockerfile \
+  .
+docker push "${ECR_REPO}":"${GIT_COMMIT}"
+docker push "${ECR_REPO}":latest
+echo "Successfully built and pushed Docker image to ${ECR_REPO}:${GIT_COMMIT} and ${ECR_REPO}:latest"
+cd ../
+
+###
+# Worker
+###
+
+cd services
+REPO_NAME="text-agent-worker"
+ECR_REPO="${AWS_ACCOUNT_ID}.dkr.ecr.${AWS_REGION}.amazonaws.com/${REPO_NAME}"
+aws ecr get-login-password --region "${AWS_REGION}" | docker login --username AWS --password-stdin "${ECR_REPO}"
+DOCKER_BUILDKIT=1 docker build \
+  -t "${ECR_REPO}":"${GIT_COMMIT}" \
+  -t "${ECR_REPO}":latest \
+  -f messaging/cmd/worker/Dockerfile \
+  .
+docker push "${ECR_REPO}":"${GIT_COMMIT}"
+docker push "${ECR_REPO}":latest
+echo "Successfully built and pushed Docker image to ${ECR_REPO}:${GIT_COMMIT} and ${ECR_REPO}:latest"
+cd ../
+
+###
+# Scheduler
+###
+
+cd services
+REPO_NAME="text-agent-scheduler"
+ECR_REPO="${AWS_ACCOUNT_ID}.dkr.ecr.${AWS_REGION}.amazonaws.com/${REPO_NAME}"
+aws ecr get-login-password --region "${AWS_REGION}" | docker login --username AWS --password-stdin "${ECR_REPO}"
+DOCKER_BUILDKIT=1 docker build \
+  -t "${ECR_REPO}":"${GIT_COMMIT}" \
+  -t "${ECR_REPO}":latest \
+  -f messaging/cmd/scheduler/Dockerfile \
+  .
+docker push "${ECR_REPO}":"${GIT_COMMIT}"
+docker push "${ECR_REPO}":latest
+echo "Successfully built and pushed Docker image to ${ECR_REPO}:${GIT_COMMIT} and ${ECR_REPO}:latest"
+cd ../
 
 ###
 # Task Tracking
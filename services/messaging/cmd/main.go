@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	_ "time/tzdata" // Participant timezones are validated with time.LoadLocation.
 
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_action_consumer"
-	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/search_index"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/secrets_service"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/wiring"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/rs/zerolog"
@@ -20,42 +23,52 @@ func main() {
 
 	ctx := context.Background()
 
-	agentAliasIdSecretId := os.Getenv("AGENT_ALIAS_ID_SECRET_ID")
-	if agentAliasIdSecretId == "" {
-		logger.Fatal().Msg("AGENT_ALIAS_ID_SECRET_ID is not set")
+	secretsService, err := secrets_service.NewAwsSecretsService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create secrets service")
 	}
 
-	agentIdSecretId := os.Getenv("AGENT_ID_SECRET_ID")
-	if agentIdSecretId == "" {
-		logger.Fatal().Msg("AGENT_ID_SECRET_ID is not set")
+	repo, err := message_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create repository")
 	}
 
-	secretsService, err := secrets_service.NewAwsSecretsService(ctx)
+	participantRepo, err := participant_repository.New(ctx)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to create secrets service")
+		logger.Fatal().Err(err).Msg("failed to create participant repository")
 	}
 
-	agentAliasId, err := secretsService.GetSecret(ctx, agentAliasIdSecretId)
+	complianceService, err := wiring.ComplianceService(ctx)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to get agent alias ID")
+		logger.Fatal().Err(err).Msg("failed to create compliance service")
 	}
 
-	agentId, err := secretsService.GetSecret(ctx, agentIdSecretId)
+	attachmentService, err := wiring.AttachmentService(ctx)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to get agent ID")
+		logger.Fatal().Err(err).Msg("failed to create attachment service")
 	}
 
-	agentService, err := agent_service.NewAws(ctx, agentAliasId, agentId)
+	deliveryService, err := wiring.DeliveryService(ctx, secretsService, complianceService, repo)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to create agent service")
+		logger.Fatal().Err(err).Msg("failed to create delivery service")
 	}
 
-	repo, err := message_repository.New(ctx)
+	// The search index is caught up from the repository before each search, so it only has to live as long as this
+	// Lambda instance.
+	searchIndex := search_index.NewMemory()
+
+	// The agent is run by the worker, which consumes the jobs.
+	coalesceService, err := wiring.CoalesceService(ctx)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to create repository")
+		logger.Fatal().Err(err).Msg("failed to create coalesce service")
+	}
+
+	reminderPolicyService, err := wiring.ReminderPolicyService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create reminder policy service")
 	}
 
-	consumer := agent_action_consumer.NewConsumer(agentService, repo)
+	consumer := agent_action_consumer.NewConsumer(attachmentService, coalesceService, complianceService, deliveryService, participantRepo, reminderPolicyService, repo, searchIndex)
 
 	requestWrapper := func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
 		lc, _ := lambdacontext.FromContext(ctx)
@@ -0,0 +1,125 @@
+// scheduler texts conversations about their open tasks that are due soon or overdue. It runs as a Lambda on a
+// schedule, or locally on a ticker:
+//
+//	go run ./cmd/scheduler -local -every 1m
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Reminders go out in the conversation's timezone.
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/secrets_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/wiring"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+	local := flag.Bool("local", false, "run on a ticker instead of as a Lambda")
+	every := flag.Duration("every", 15*time.Minute, "how often to run with -local")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	secretsService, err := secrets_service.NewAwsSecretsService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create secrets service")
+	}
+
+	repo, err := message_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create repository")
+	}
+
+	participantRepo, err := participant_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create participant repository")
+	}
+
+	complianceService, err := wiring.ComplianceService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create compliance service")
+	}
+
+	deliveryService, err := wiring.DeliveryService(ctx, secretsService, complianceService, repo)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create delivery service")
+	}
+
+	taskRepo, err := task_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create task repository")
+	}
+
+	reminderRepo, err := reminder_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create reminder repository")
+	}
+
+	reminderPolicyService, err := wiring.ReminderPolicyService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create reminder policy service")
+	}
+
+	defaultLocation, err := due_date.ParseLocation(os.Getenv("DEFAULT_TIMEZONE"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse default timezone")
+	}
+
+	reminderService := reminder_service.NewReminderService(defaultLocation, deliveryService, participantRepo, reminderPolicyService, reminderRepo, repo, taskRepo)
+
+	if *local {
+		ctx, stop := signal.NotifyContext(logger.WithContext(ctx), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(*every)
+		defer ticker.Stop()
+		for {
+			if err := reminderService.Run(ctx, time.Now()); err != nil {
+				logger.Error().Err(err).Msg("failed to send reminders")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+
+	requestWrapper := func(ctx context.Context, event events.EventBridgeEvent) error {
+		lc, _ := lambdacontext.FromContext(ctx)
+		requestID := "unknown"
+		if lc != nil {
+			requestID = lc.AwsRequestID
+		}
+		logger := logger.With().Str("request_id", requestID).Logger()
+		ctx = logger.WithContext(ctx)
+
+		logger.Info().Time("scheduled_at", event.Time).Msg("sending reminders")
+
+		// Conversations that failed are retried on the next run, which won't send the others' reminders again.
+		if err := reminderService.Run(ctx, time.Now()); err != nil {
+			logger.Error().Err(err).Msg("failed to send some reminders")
+		}
+
+		return nil
+	}
+
+	lambda.Start(requestWrapper)
+}
@@ -0,0 +1,58 @@
+// trace_reader prints stored agent traces as JSON, for debugging the agent's decisions:
+//
+//	go run ./cmd/trace_reader -conversation +15555550100_+15555550101
+//	go run ./cmd/trace_reader -invocation <invocation ID>
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/trace_repository"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	conversationId := flag.String("conversation", "", "list the conversation's most recent invocations")
+	invocationId := flag.String("invocation", "", "print the invocation's full timeline")
+	limit := flag.Int("limit", 20, "how many invocations to list")
+	flag.Parse()
+
+	if (*conversationId == "") == (*invocationId == "") {
+		logger.Fatal().Msg("exactly one of -conversation or -invocation has to be set")
+	}
+
+	ctx := context.Background()
+
+	repo, err := trace_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create trace repository")
+	}
+
+	var output interface{}
+	if *conversationId != "" {
+		output, err = repo.ListInvocationsByConversation(*conversationId, *limit)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to list invocations")
+		}
+	} else {
+		timeline, err := repo.GetTimeline(*invocationId)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to get timeline")
+		}
+		if timeline == nil {
+			logger.Fatal().Str("invocation_id", *invocationId).Msg("invocation not found")
+		}
+		output = timeline
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		logger.Fatal().Err(err).Msg("failed to write output")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/secrets_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/twilio_webhook"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/wiring"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+	ctx := context.Background()
+
+	secretsService, err := secrets_service.NewAwsSecretsService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create secrets service")
+	}
+
+	twilioAuthToken, err := wiring.Secret(ctx, secretsService, "TWILIO_AUTH_TOKEN_SECRET_ID")
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to get twilio auth token")
+	}
+
+	repo, err := message_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create repository")
+	}
+
+	complianceService, err := wiring.ComplianceService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create compliance service")
+	}
+
+	attachmentService, err := wiring.AttachmentService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create attachment service")
+	}
+
+	// The agent is run by the worker, which consumes the jobs.
+	coalesceService, err := wiring.CoalesceService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create coalesce service")
+	}
+
+	handler := twilio_webhook.NewHandler(twilioAuthToken, coalesceService, complianceService, &http.Client{Timeout: 10 * time.Second}, attachmentService, repo)
+
+	requestWrapper := func(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		lc, _ := lambdacontext.FromContext(ctx)
+		requestID := "unknown"
+		if lc != nil {
+			requestID = lc.AwsRequestID
+		}
+		logger := logger.With().Str("request_id", requestID).Logger()
+		ctx = logger.WithContext(ctx)
+
+		logger.Info().Str("path", request.RawPath).Msg("received webhook")
+
+		response, err := handler.HandleRequest(ctx, request)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to handle webhook")
+			return response, err
+		}
+
+		logger.Info().Int("status_code", response.StatusCode).Msg("sending response")
+		return response, nil
+	}
+
+	lambda.Start(requestWrapper)
+}
@@ -0,0 +1,57 @@
+// usage_report prints the conversations that cost the most over a range of days (UTC, inclusive) as JSON:
+//
+//	go run ./cmd/usage_report -from 2025-07-01 -to 2025-07-31 -limit 10
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_service"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+	today := time.Now().UTC().Format("2006-01-02")
+	fromFlag := flag.String("from", today, "first day of the range (YYYY-MM-DD)")
+	toFlag := flag.String("to", today, "last day of the range (YYYY-MM-DD)")
+	limit := flag.Int("limit", 10, "how many conversations to list")
+	flag.Parse()
+
+	from, err := usage_service.ParseDay(*fromFlag)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid -from")
+	}
+
+	to, err := usage_service.ParseDay(*toFlag)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid -to")
+	}
+
+	ctx := logger.WithContext(context.Background())
+
+	repo, err := usage_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create usage repository")
+	}
+
+	// Costs are priced when the usage is recorded, so the report doesn't need the prices.
+	usageService := usage_service.NewUsageService(usage_service.DefaultPrices, repo)
+
+	top, err := usageService.TopConversations(ctx, from, to, *limit)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to get top conversations")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(top); err != nil {
+		logger.Fatal().Err(err).Msg("failed to write output")
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"os"
+	_ "time/tzdata" // Participant timezones are validated with time.LoadLocation.
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/action_dispatcher"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_action_consumer"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_invoker"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation_lock"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/job_queue"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/search_index"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/secrets_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/wiring"
+	task_tracking "github.com/anthonywittig/text-agent/services/task_tracking/pkg/agent_action_consumer"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/rs/zerolog"
+)
+
+// The worker runs the agent for the jobs on the job queue; the other Lambdas only save messages and enqueue jobs.
+func main() {
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+	ctx := context.Background()
+
+	secretsService, err := secrets_service.NewAwsSecretsService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create secrets service")
+	}
+
+	// Action groups that return control to us are run in-process; the handlers are registered once they're built.
+	router := action_dispatcher.NewRouter()
+
+	agentService, err := wiring.AgentService(ctx, secretsService, router)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create agent service")
+	}
+
+	repo, err := message_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create repository")
+	}
+
+	participantRepo, err := participant_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create participant repository")
+	}
+
+	complianceService, err := wiring.ComplianceService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create compliance service")
+	}
+
+	attachmentService, err := wiring.AttachmentService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create attachment service")
+	}
+
+	deliveryService, err := wiring.DeliveryService(ctx, secretsService, complianceService, repo)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create delivery service")
+	}
+
+	// The search index is caught up from the repository before each search, so it only has to live as long as this
+	// Lambda instance.
+	searchIndex := search_index.NewMemory()
+
+	rateLimitService, err := wiring.RateLimitService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create rate limit service")
+	}
+
+	conversationLock, err := conversation_lock.NewDynamo(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create conversation lock")
+	}
+
+	agentInvoker := agent_invoker.NewInvoker(agentService, deliveryService, conversationLock, rateLimitService, repo)
+
+	// Jobs that aren't due yet are put back on the queue.
+	coalesceService, err := wiring.CoalesceService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create coalesce service")
+	}
+	jobHandler := coalesceService.JobHandler(agentInvoker)
+
+	reminderPolicyService, err := wiring.ReminderPolicyService(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create reminder policy service")
+	}
+
+	consumer := agent_action_consumer.NewConsumer(attachmentService, coalesceService, complianceService, deliveryService, participantRepo, reminderPolicyService, repo, searchIndex)
+
+	taskRepo, err := task_repository.New(ctx)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create task repository")
+	}
+
+	taskDedupWindow, err := task_tracking.ParseDedupWindow(os.Getenv("TASK_DEDUP_WINDOW"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse task dedup window")
+	}
+
+	defaultLocation, err := due_date.ParseLocation(os.Getenv("DEFAULT_TIMEZONE"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse default timezone")
+	}
+
+	// Relative due dates are worked out from when the source message was sent, which the task tracking consumer can't
+	// look up itself.
+	anchorSource := action_dispatcher.NewAnchorSource(defaultLocation, participantRepo, repo)
+
+	router.Register("Messaging", consumer.HandleRequest)
+	router.Register("TaskTracking", action_dispatcher.TaskTracking(task_tracking.NewConsumer(anchorSource, taskDedupWindow, taskRepo)))
+
+	requestWrapper := func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		lc, _ := lambdacontext.FromContext(ctx)
+		requestID := "unknown"
+		if lc != nil {
+			requestID = lc.AwsRequestID
+		}
+		logger := logger.With().Str("request_id", requestID).Logger()
+		ctx = logger.WithContext(ctx)
+
+		logger.Info().Int("records", len(event.Records)).Msg("received jobs")
+
+		response := job_queue.HandleSqsEvent(ctx, event, jobHandler)
+
+		logger.Info().Int("failures", len(response.BatchItemFailures)).Msg("finished jobs")
+		return response, nil
+	}
+
+	lambda.Start(requestWrapper)
+}
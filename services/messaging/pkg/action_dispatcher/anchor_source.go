@@ -0,0 +1,63 @@
+package action_dispatcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+)
+
+// AnchorSource tells the task tracking consumer when a due date was put (the source message's sent time, in the
+// conversation's timezone), so it doesn't have to read our messages and participants itself.
+type AnchorSource struct {
+	defaultLocation *time.Location
+	participantRepo participant_repository.ParticipantRepository
+	repo            message_repository.MessageRepository
+}
+
+// defaultLocation is the timezone of conversations where no one has set theirs.
+func NewAnchorSource(defaultLocation *time.Location, participantRepo participant_repository.ParticipantRepository, repo message_repository.MessageRepository) *AnchorSource {
+	return &AnchorSource{
+		defaultLocation: defaultLocation,
+		participantRepo: participantRepo,
+		repo:            repo,
+	}
+}
+
+// Anchor trusts the stored message over the sent time the agent passed.
+func (s *AnchorSource) Anchor(ctx context.Context, conversationId string, source due_date.Source) (time.Time, error) {
+	sentAt := time.Now()
+	if !source.SentAt.IsZero() {
+		sentAt = source.SentAt
+	}
+	if source.MessageId != "" {
+		message, err := s.repo.GetMessage(source.MessageId)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get message: %w", err)
+		}
+		if message.ConversationId != conversationId {
+			return time.Time{}, fmt.Errorf("message not found in the conversation with ID: %s", source.MessageId)
+		}
+		sentAt = time.UnixMilli(message.SentAt)
+	}
+
+	location, err := s.location(conversationId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return sentAt.In(location), nil
+}
+
+// location is the conversation's timezone, the same one its reminders go by.
+func (s *AnchorSource) location(conversationId string) (*time.Location, error) {
+	participants, err := s.participantRepo.ListParticipantsByConversation(conversationId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list participants: %w", err)
+	}
+
+	return participant_repository.ConversationLocation(participants, s.defaultLocation), nil
+}
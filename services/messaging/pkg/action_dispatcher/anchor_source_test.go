@@ -0,0 +1,115 @@
+package action_dispatcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+)
+
+// fakeMessageRepository embeds the interface so only the methods the anchor source uses need implementing.
+type fakeMessageRepository struct {
+	message_repository.MessageRepository
+	messages map[string]*message_repository.Message
+}
+
+func (r *fakeMessageRepository) GetMessage(id string) (*message_repository.Message, error) {
+	message, ok := r.messages[id]
+	if !ok {
+		return nil, fmt.Errorf("message not found with ID: %s", id)
+	}
+	return message, nil
+}
+
+type fakeParticipantRepository struct {
+	participant_repository.ParticipantRepository
+	participants []*participant_repository.Participant
+}
+
+func (r *fakeParticipantRepository) ListParticipantsByConversation(conversationId string) ([]*participant_repository.Participant, error) {
+	return r.participants, nil
+}
+
+func TestAnchor(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101_+15555550102"
+	sentAt := time.Date(2025, 8, 22, 3, 0, 0, 0, time.UTC)
+
+	repo := &fakeMessageRepository{messages: map[string]*message_repository.Message{
+		"m1": {Id: "m1", ConversationId: conversationId, From: "+15555550101", SentAt: sentAt.UnixMilli()},
+		"m2": {Id: "m2", ConversationId: conversationId, From: "+15555550102", SentAt: sentAt.UnixMilli()},
+		"m3": {Id: "m3", ConversationId: "+15555550100_+15555550199", From: "+15555550199", SentAt: sentAt.UnixMilli()},
+	}}
+
+	tests := []struct {
+		name         string
+		participants []*participant_repository.Participant
+		source       due_date.Source
+		wantAnchor   time.Time
+		wantLocation string
+		wantErr      bool
+	}{
+		{
+			name: "conversation's timezone, whoever sent the message",
+			participants: []*participant_repository.Participant{
+				{PhoneNumber: "+15555550101", Timezone: "America/Denver"},
+				{PhoneNumber: "+15555550102", Timezone: "America/New_York"},
+			},
+			source:       due_date.Source{MessageId: "m2"},
+			wantAnchor:   sentAt,
+			wantLocation: "America/Denver",
+		},
+		{
+			name:         "default timezone",
+			source:       due_date.Source{MessageId: "m1"},
+			wantAnchor:   sentAt,
+			wantLocation: "UTC",
+		},
+		{
+			name: "stored sent time over the agent's",
+			participants: []*participant_repository.Participant{
+				{PhoneNumber: "+15555550101", Timezone: "America/Denver"},
+			},
+			source:       due_date.Source{MessageId: "m1", SentAt: sentAt.Add(time.Hour)},
+			wantAnchor:   sentAt,
+			wantLocation: "America/Denver",
+		},
+		{
+			name: "agent's sent time without a message ID",
+			participants: []*participant_repository.Participant{
+				{PhoneNumber: "+15555550101", Timezone: "America/Denver"},
+			},
+			source:       due_date.Source{SentAt: sentAt.Add(time.Hour)},
+			wantAnchor:   sentAt.Add(time.Hour),
+			wantLocation: "America/Denver",
+		},
+		{
+			name:    "message from another conversation",
+			source:  due_date.Source{MessageId: "m3"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := NewAnchorSource(time.UTC, &fakeParticipantRepository{participants: tt.participants}, repo)
+
+			anchor, err := source.Anchor(context.Background(), conversationId, tt.source)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Anchor() = %v, want an error", anchor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Anchor() error = %v", err)
+			}
+			if !anchor.Equal(tt.wantAnchor) || anchor.Location().String() != tt.wantLocation {
+				t.Errorf("Anchor() = %v, want %v in %s", anchor, tt.wantAnchor, tt.wantLocation)
+			}
+		})
+	}
+}
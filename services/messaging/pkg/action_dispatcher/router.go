@@ -0,0 +1,34 @@
+package action_dispatcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+)
+
+// Handler has the same shape as our Lambda action group consumers' HandleRequest.
+type Handler func(ctx context.Context, request types.AgentRequest) (types.AgentResponse, error)
+
+// Router dispatches returned control to the handler registered for the action group.
+type Router struct {
+	handlers map[string]Handler
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: map[string]Handler{}}
+}
+
+// Register sets the handler for an action group; actionGroup is the group's name as configured on the agent.
+func (r *Router) Register(actionGroup string, handler Handler) {
+	r.handlers[actionGroup] = handler
+}
+
+func (r *Router) Dispatch(ctx context.Context, request types.AgentRequest) (types.AgentResponse, error) {
+	handler, ok := r.handlers[request.ActionGroup]
+	if !ok {
+		return types.AgentResponse{}, fmt.Errorf("no handler for action group: %s", request.ActionGroup)
+	}
+
+	return handler(ctx, request)
+}
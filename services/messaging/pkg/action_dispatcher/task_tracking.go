@@ -0,0 +1,41 @@
+package action_dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	task_tracking "github.com/anthonywittig/text-agent/services/task_tracking/pkg/agent_action_consumer"
+)
+
+// TaskTracking adapts the task tracking consumer. Its request and response types are its own copy of the Bedrock
+// Lambda event, so we convert through JSON, the same way the event reaches its Lambda.
+func TaskTracking(consumer *task_tracking.Consumer) Handler {
+	return func(ctx context.Context, request types.AgentRequest) (types.AgentResponse, error) {
+		var taskTrackingRequest task_tracking.AgentRequest
+		if err := convert(request, &taskTrackingRequest); err != nil {
+			return types.AgentResponse{}, fmt.Errorf("failed to convert request: %w", err)
+		}
+
+		taskTrackingResponse, err := consumer.HandleRequest(ctx, taskTrackingRequest)
+		if err != nil {
+			return types.AgentResponse{}, err
+		}
+
+		var response types.AgentResponse
+		if err := convert(taskTrackingResponse, &response); err != nil {
+			return types.AgentResponse{}, fmt.Errorf("failed to convert response: %w", err)
+		}
+
+		return response, nil
+	}
+}
+
+func convert(from interface{}, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
@@ -3,19 +3,49 @@ package agent_action_consumer
 import (
 	"context"
 
-	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/attachment_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/coalesce_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/delivery_service"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/search_index"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
 	"github.com/rs/zerolog"
 )
 
 type Consumer struct {
-	agentService agent_service.AgentService
-	repo         message_repository.MessageRepository
+	attachmentService     *attachment_service.AttachmentService
+	coalesceService       *coalesce_service.CoalesceService
+	complianceService     *compliance_service.ComplianceService
+	deliveryService       *delivery_service.DeliveryService
+	participantRepo       participant_repository.ParticipantRepository
+	reminderPolicyService *reminder_service.PolicyService
+	repo                  message_repository.MessageRepository
+	searchIndex           search_index.SearchIndex
 }
 
-func NewConsumer(agentService agent_service.AgentService, repo message_repository.MessageRepository) *Consumer {
-	return &Consumer{agentService: agentService, repo: repo}
+func NewConsumer(
+	attachmentService *attachment_service.AttachmentService,
+	coalesceService *coalesce_service.CoalesceService,
+	complianceService *compliance_service.ComplianceService,
+	deliveryService *delivery_service.DeliveryService,
+	participantRepo participant_repository.ParticipantRepository,
+	reminderPolicyService *reminder_service.PolicyService,
+	repo message_repository.MessageRepository,
+	searchIndex search_index.SearchIndex,
+) *Consumer {
+	return &Consumer{
+		attachmentService:     attachmentService,
+		coalesceService:       coalesceService,
+		complianceService:     complianceService,
+		deliveryService:       deliveryService,
+		participantRepo:       participantRepo,
+		reminderPolicyService: reminderPolicyService,
+		repo:                  repo,
+		searchIndex:           searchIndex,
+	}
 }
 
 func (c *Consumer) HandleRequest(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
@@ -26,6 +56,20 @@ func (c *Consumer) HandleRequest(ctx context.Context, payload types.AgentRequest
 		return c.handleMessageCreate(ctx, payload)
 	case "messaging_list_recent":
 		return c.handleMessageListRecent(ctx, payload)
+	case "messaging_list_range":
+		return c.handleMessageListRange(ctx, payload)
+	case "messaging_search":
+		return c.handleMessageSearch(ctx, payload)
+	case "messaging_get_attachment":
+		return c.handleMessageGetAttachment(ctx, payload)
+	case "participants_list":
+		return c.handleParticipantsList(ctx, payload)
+	case "participants_set_name":
+		return c.handleParticipantsSetName(ctx, payload)
+	case "reminders_get_policy":
+		return c.handleRemindersGetPolicy(ctx, payload)
+	case "reminders_set_policy":
+		return c.handleRemindersSetPolicy(ctx, payload)
 	default:
 		logger.Error().Str("function", payload.Function).Msg("unknown function")
 		return types.AgentResponse{
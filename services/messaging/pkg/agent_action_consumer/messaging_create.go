@@ -3,8 +3,8 @@ package agent_action_consumer
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
 	"github.com/nyaruka/phonenumbers"
@@ -33,22 +33,60 @@ func (c *Consumer) handleMessageCreate(ctx context.Context, payload types.AgentR
 		from = phonenumbers.Format(parsedFrom, phonenumbers.E164)
 	}
 
-	message, err := c.repo.CreateMessage(
+	// Participants' messages might be compliance keywords, which the agent shouldn't act on.
+	keyword, reply := compliance_service.KeywordNone, ""
+	if payload.Agent.Name == "" && from != message_repository.FromAssistant {
+		keyword, reply, err = c.complianceService.HandleInbound(ctx, from, getParameter(payload, "body"))
+		if err != nil {
+			return getFailureResponse(payload, err.Error()), nil
+		}
+	}
+
+	// Callers that might retry (e.g. scripts) can pass an idempotency key so a retry doesn't create the message again.
+	message, duplicate, err := c.repo.CreateMessageWithExternalId(
 		conversationId,
+		getParameter(payload, "idempotency_key"),
 		from,
 		getParameter(payload, "body"),
+		nil,
 	)
 	if err != nil {
 		return getFailureResponse(payload, err.Error()), nil
 	}
 
-	err = c.invokeAgent(ctx, payload)
-	if err != nil {
-		return getFailureResponse(payload, err.Error()), nil
+	if duplicate {
+		logger.Info().Str("message_id", message.Id).Msg("message already created")
+		return messageCreateResponse(payload, "Message already created", message)
+	}
+
+	if message.From == message_repository.FromAssistant {
+		message, err = c.deliveryService.Deliver(ctx, message)
+		if err != nil {
+			return getFailureResponse(payload, err.Error()), nil
+		}
+	}
+
+	if reply != "" {
+		// The webhook answers in its TwiML response; here we have to text it. The message is saved either way.
+		if err := c.deliveryService.Reply(ctx, from, reply); err != nil {
+			logger.Error().Err(err).Str("from", from).Msg("failed to reply to keyword")
+		}
 	}
 
+	if keyword == compliance_service.KeywordNone {
+		// The message is saved either way; failing here would only get it created again.
+		err = c.invokeAgent(ctx, conversationId, message.Id, payload)
+		if err != nil {
+			logger.Error().Err(err).Str("conversation_id", conversationId).Msg("failed to schedule agent run")
+		}
+	}
+
+	return messageCreateResponse(payload, "Message created successfully", message)
+}
+
+func messageCreateResponse(payload types.AgentRequest, info string, message *message_repository.Message) (types.AgentResponse, error) {
 	response := MessageCreateResponse{
-		Info:    "Message created successfully",
+		Info:    info,
 		Message: message,
 	}
 	responseJson, err := json.Marshal(response)
@@ -73,7 +111,7 @@ func (c *Consumer) handleMessageCreate(ctx context.Context, payload types.AgentR
 	}, nil
 }
 
-func (c *Consumer) invokeAgent(ctx context.Context, payload types.AgentRequest) error {
+func (c *Consumer) invokeAgent(ctx context.Context, conversationId, messageId string, payload types.AgentRequest) error {
 	logger := zerolog.Ctx(ctx)
 
 	// If the message is from an agent, don't do anything.
@@ -82,10 +120,6 @@ func (c *Consumer) invokeAgent(ctx context.Context, payload types.AgentRequest)
 		return nil
 	}
 
-	err := c.agentService.InvokeAgent(ctx, "A new message was received for the conversation between these numbers: "+getParameter(payload, "conversation_phone_numbers"))
-	if err != nil {
-		return fmt.Errorf("failed to invoke agent: %w", err)
-	}
-
-	return nil
+	// The agent runs once the conversation quiets down, for all of the messages that came in until then.
+	return c.coalesceService.MessageReceived(ctx, conversationId, messageId)
 }
@@ -0,0 +1,79 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+type MessageGetAttachmentResponse struct {
+	Attachment  *message_repository.Attachment `json:"attachment"`
+	DownloadUrl string                         `json:"download_url"`
+}
+
+func (c *Consumer) handleMessageGetAttachment(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	messageId := getParameter(payload, "message_id")
+	attachmentId := getParameter(payload, "attachment_id")
+
+	message, err := c.repo.GetMessage(messageId)
+	if err != nil {
+		logger.Error().Err(err).Str("message_id", messageId).Msg("Failed to get message")
+		return getFailureResponse(payload, "Message not found"), nil
+	}
+
+	// Don't let the agent read attachments from other conversations.
+	if message.ConversationId != conversationId {
+		logger.Warn().Str("message_id", messageId).Str("conversation_id", conversationId).Msg("message is from another conversation")
+		return getFailureResponse(payload, "Message not found"), nil
+	}
+
+	var attachment *message_repository.Attachment
+	for _, a := range message.Attachments {
+		if a.Id == attachmentId {
+			attachment = a
+		}
+	}
+	if attachment == nil {
+		return getFailureResponse(payload, "Attachment not found"), nil
+	}
+
+	downloadUrl, err := c.attachmentService.DownloadUrl(ctx, attachment)
+	if err != nil {
+		logger.Error().Err(err).Str("attachment_id", attachmentId).Msg("Failed to get download URL")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	responseJson, err := json.Marshal(MessageGetAttachmentResponse{
+		Attachment:  attachment,
+		DownloadUrl: downloadUrl,
+	})
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
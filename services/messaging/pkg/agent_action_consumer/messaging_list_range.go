@@ -0,0 +1,100 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+type MessageListRangeResponse struct {
+	Messages []*MessageView `json:"messages"`
+	// NextCursor is passed back as `cursor` to get the next page; it's empty when there are no more messages.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (c *Consumer) handleMessageListRange(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	after, err := parseTimeParameter(getParameter(payload, "after"))
+	if err != nil {
+		return getFailureResponse(payload, "Invalid after: "+err.Error()), nil
+	}
+
+	before, err := parseTimeParameter(getParameter(payload, "before"))
+	if err != nil {
+		return getFailureResponse(payload, "Invalid before: "+err.Error()), nil
+	}
+
+	if after > 0 && before > 0 && before <= after {
+		return getFailureResponse(payload, "Invalid range: before has to be later than after"), nil
+	}
+
+	var pageSize int64
+	if value := getParameter(payload, "page_size"); value != "" {
+		pageSize, err = strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return getFailureResponse(payload, "Invalid page_size"), nil
+		}
+	}
+
+	logger.Info().
+		Str("conversation_id", conversationId).
+		Int64("after", after).
+		Int64("before", before).
+		Msg("Processing conversation")
+
+	page, err := c.repo.ListMessages(ctx, conversationId, message_repository.ListMessagesOptions{
+		Cursor:      getParameter(payload, "cursor"),
+		After:       after,
+		Before:      before,
+		PageSize:    int32(pageSize),
+		NewestFirst: getParameter(payload, "newest_first") == "true",
+	})
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to list messages")
+		return getFailureResponse(payload, "Failed to list messages"), nil
+	}
+
+	names, err := c.participantNames(conversationId)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get participant names")
+	}
+
+	response := MessageListRangeResponse{
+		Messages:   make([]*MessageView, len(page.Messages)),
+		NextCursor: page.NextCursor,
+	}
+	for i, message := range page.Messages {
+		response.Messages[i] = newMessageView(message, names)
+	}
+
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -3,11 +3,48 @@ package agent_action_consumer
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"time"
 
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
 	"github.com/rs/zerolog"
 )
 
+// MessageView is what the agent sees for a message.
+type MessageView struct {
+	*message_repository.Message
+	// FromName is the sender's display name from the participant directory, if we know it.
+	FromName string `json:"from_name,omitempty"`
+	// SentAtUtc makes it easier for the agent to reason about dates than `sent_at`.
+	SentAtUtc string `json:"sent_at_utc"`
+	// FailedDeliveries lists the numbers an Assistant message didn't reach, so the agent can re-send or tell the group.
+	FailedDeliveries []string `json:"failed_deliveries,omitempty"`
+}
+
+// names maps phone numbers to display names, see participantNames.
+func newMessageView(message *message_repository.Message, names map[string]string) *MessageView {
+	view := &MessageView{
+		Message:   message,
+		FromName:  names[message.From],
+		SentAtUtc: time.UnixMilli(message.SentAt).UTC().Format(time.RFC3339),
+	}
+	for to, delivery := range message.Deliveries {
+		if delivery.Failed() {
+			view.FailedDeliveries = append(view.FailedDeliveries, to)
+		}
+	}
+	sort.Strings(view.FailedDeliveries)
+	return view
+}
+
+type MessageListRecentResponse struct {
+	Messages []*MessageView `json:"messages"`
+	// OptedOut lists the participants that texted STOP; they won't receive any messages until they text START.
+	OptedOut []string `json:"opted_out"`
+}
+
 func (c *Consumer) handleMessageListRecent(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
 	logger := zerolog.Ctx(ctx)
 
@@ -24,7 +61,27 @@ func (c *Consumer) handleMessageListRecent(ctx context.Context, payload types.Ag
 		return getFailureResponse(payload, "Internal error"), nil
 	}
 
-	messageString, err := json.Marshal(messages)
+	names, err := c.participantNames(conversationId)
+	if err != nil {
+		// Names are nice to have; the agent can still work with phone numbers.
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get participant names")
+	}
+
+	messageViews := make([]*MessageView, len(messages))
+	for i, message := range messages {
+		messageViews[i] = newMessageView(message, names)
+	}
+
+	optedOut, err := c.complianceService.OptedOut(conversation.PhoneNumbers(conversationId))
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get opt outs")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	messageString, err := json.Marshal(MessageListRecentResponse{
+		Messages: messageViews,
+		OptedOut: optedOut,
+	})
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to marshal messages")
 	}
@@ -0,0 +1,74 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/search_index"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+const defaultSearchLimit = 10
+
+type MessageSearchResponse struct {
+	Results []*search_index.SearchResult `json:"results"`
+}
+
+func (c *Consumer) handleMessageSearch(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	query := strings.TrimSpace(getParameter(payload, "query"))
+	if query == "" {
+		return getFailureResponse(payload, "query is required"), nil
+	}
+
+	limit := defaultSearchLimit
+	if value := getParameter(payload, "limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return getFailureResponse(payload, "Invalid limit"), nil
+		}
+	}
+
+	logger.Info().Str("conversation_id", conversationId).Str("query", query).Msg("Processing conversation")
+
+	if err := search_index.Sync(ctx, c.searchIndex, c.repo, conversationId); err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to sync search index")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	results, err := c.searchIndex.Search(ctx, conversationId, query, limit)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to search messages")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	responseJson, err := json.Marshal(MessageSearchResponse{Results: results})
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
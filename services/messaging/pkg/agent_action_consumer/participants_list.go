@@ -0,0 +1,90 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+func (c *Consumer) handleParticipantsList(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	logger.Info().Str("conversation_id", conversationId).Msg("Processing conversation")
+
+	participants, err := c.listParticipants(conversationId)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to list participants")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	participantString, err := json.Marshal(participants)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal participants")
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(participantString),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// listParticipants returns every number in the conversation, including the ones nobody has named yet.
+func (c *Consumer) listParticipants(conversationId string) ([]*participant_repository.Participant, error) {
+	saved, err := c.participantRepo.ListParticipantsByConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	byPhoneNumber := map[string]*participant_repository.Participant{}
+	for _, participant := range saved {
+		byPhoneNumber[participant.PhoneNumber] = participant
+	}
+
+	phoneNumbers := conversation.PhoneNumbers(conversationId)
+	participants := make([]*participant_repository.Participant, len(phoneNumbers))
+	for i, phoneNumber := range phoneNumbers {
+		participant, ok := byPhoneNumber[phoneNumber]
+		if !ok {
+			participant = &participant_repository.Participant{ConversationId: conversationId, PhoneNumber: phoneNumber}
+		}
+		participants[i] = participant
+	}
+
+	return participants, nil
+}
+
+// participantNames maps phone numbers to display names for the participants that have one.
+func (c *Consumer) participantNames(conversationId string) (map[string]string, error) {
+	participants, err := c.participantRepo.ListParticipantsByConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, participant := range participants {
+		if participant.DisplayName != "" {
+			names[participant.PhoneNumber] = participant.DisplayName
+		}
+	}
+	return names, nil
+}
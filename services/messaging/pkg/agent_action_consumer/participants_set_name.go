@@ -0,0 +1,95 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+type ParticipantsSetNameResponse struct {
+	Message     string                              `json:"message"`
+	Participant *participant_repository.Participant `json:"participant"`
+}
+
+// handleParticipantsSetName only changes the fields that are passed; aliases are replaced as a whole.
+func (c *Consumer) handleParticipantsSetName(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleParticipantsSetName")
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	phoneNumber, err := conversation.ToE164(getParameter(payload, "phone_number"))
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	if !slices.Contains(conversation.PhoneNumbers(conversationId), phoneNumber) {
+		return getFailureResponse(payload, "phone_number is not part of the conversation"), nil
+	}
+
+	timezone := strings.TrimSpace(getParameter(payload, "timezone"))
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return getFailureResponse(payload, "Invalid timezone, use an IANA name like America/Denver"), nil
+		}
+	}
+
+	participant, err := c.participantRepo.GetParticipant(conversationId, phoneNumber)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get participant")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+	if participant == nil {
+		participant = &participant_repository.Participant{ConversationId: conversationId, PhoneNumber: phoneNumber}
+	}
+
+	if displayName := strings.TrimSpace(getParameter(payload, "display_name")); displayName != "" {
+		participant.DisplayName = displayName
+	}
+	if aliases := getArrayParameter(payload, "aliases"); aliases != nil {
+		participant.Aliases = aliases
+	}
+	if timezone != "" {
+		participant.Timezone = timezone
+	}
+
+	participant, err = c.participantRepo.SaveParticipant(participant)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	responseJson, err := json.Marshal(ParticipantsSetNameResponse{
+		Message:     "Participant saved successfully",
+		Participant: participant,
+	})
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,73 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+type RemindersPolicyResponse struct {
+	Message string          `json:"message"`
+	Policy  RemindersPolicy `json:"policy"`
+}
+
+// RemindersPolicy is a reminder_service.Policy in the form the agent passes it.
+type RemindersPolicy struct {
+	LeadTime    string `json:"lead_time"`
+	RepeatEvery string `json:"repeat_every"`
+	QuietHours  string `json:"quiet_hours"`
+	Paused      bool   `json:"paused"`
+}
+
+func (c *Consumer) handleRemindersGetPolicy(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleRemindersGetPolicy")
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	policy, err := c.reminderPolicyService.GetPolicy(conversationId)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get reminder policy")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	return remindersPolicyResponse(payload, "Reminder policy for the conversation", policy)
+}
+
+func remindersPolicyResponse(payload types.AgentRequest, message string, policy reminder_service.Policy) (types.AgentResponse, error) {
+	responseJson, err := json.Marshal(RemindersPolicyResponse{
+		Message: message,
+		Policy: RemindersPolicy{
+			LeadTime:    policy.LeadTime.String(),
+			RepeatEvery: policy.RepeatEvery.String(),
+			QuietHours:  policy.QuietHours.String(),
+			Paused:      policy.Paused,
+		},
+	})
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
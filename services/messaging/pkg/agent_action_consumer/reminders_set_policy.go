@@ -0,0 +1,61 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+// handleRemindersSetPolicy only changes the settings that are passed; the rest stay as they are (or as the default).
+func (c *Consumer) handleRemindersSetPolicy(ctx context.Context, payload types.AgentRequest) (types.AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleRemindersSetPolicy")
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	policy, err := c.reminderPolicyService.GetPolicy(conversationId)
+	if err != nil {
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to get reminder policy")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	if leadTime := strings.TrimSpace(getParameter(payload, "lead_time")); leadTime != "" {
+		policy.LeadTime, err = reminder_service.ParseLeadTime(leadTime)
+		if err != nil {
+			return getFailureResponse(payload, "Invalid lead_time, use a duration like 24h of up to 168h"), nil
+		}
+	}
+	if repeatEvery := strings.TrimSpace(getParameter(payload, "repeat_every")); repeatEvery != "" {
+		policy.RepeatEvery, err = reminder_service.ParseRepeatEvery(repeatEvery)
+		if err != nil {
+			return getFailureResponse(payload, "Invalid repeat_every, use 0 or a duration from 1h to 168h"), nil
+		}
+	}
+	if quietHours := strings.TrimSpace(getParameter(payload, "quiet_hours")); quietHours != "" {
+		policy.QuietHours, err = reminder_service.ParseQuietHours(quietHours)
+		if err != nil {
+			return getFailureResponse(payload, "Invalid quiet_hours, use a span like 21:00-08:00 or none"), nil
+		}
+	}
+	if paused := strings.TrimSpace(getParameter(payload, "paused")); paused != "" {
+		policy.Paused, err = strconv.ParseBool(paused)
+		if err != nil {
+			return getFailureResponse(payload, "Invalid paused, use true or false"), nil
+		}
+	}
+
+	policy, err = c.reminderPolicyService.SavePolicy(conversationId, policy)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return remindersPolicyResponse(payload, "Reminder policy saved successfully", policy)
+}
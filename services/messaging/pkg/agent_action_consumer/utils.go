@@ -2,37 +2,26 @@ package agent_action_consumer
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
 	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
 	"github.com/rs/zerolog"
-	"github.com/ttacon/libphonenumber"
 )
 
 func getConversationId(ctx context.Context, payload types.AgentRequest) (string, error) {
 	logger := zerolog.Ctx(ctx)
 
 	phoneNumbers := strings.Split(strings.Trim(getParameter(payload, "conversation_phone_numbers"), "[]"), ",")
-	if len(phoneNumbers) == 0 {
-		logger.Error().Msg("no phone numbers found")
-		return "", errors.New("no phone numbers found")
+	conversationId, err := conversation.Id(phoneNumbers)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get conversation ID")
+		return "", err
 	}
 
-	e164PhoneNumbers := make([]string, len(phoneNumbers))
-	for i, phoneNumber := range phoneNumbers {
-		number, err := libphonenumber.Parse(phoneNumber, "US")
-		if err != nil {
-			logger.Error().Err(err).Msg("failed to parse phone number")
-			return "", errors.New("failed to parse phone number " + phoneNumber)
-		}
-		e164PhoneNumbers[i] = libphonenumber.Format(number, libphonenumber.E164)
-	}
-
-	sort.Strings(e164PhoneNumbers)
-	conversationId := strings.Join(e164PhoneNumbers, "_")
 	return conversationId, nil
 }
 
@@ -62,3 +51,42 @@ func getParameter(payload types.AgentRequest, name string) string {
 	}
 	return ""
 }
+
+// getArrayParameter parses an `array` parameter, which Bedrock passes as e.g. `[a, b]`. It returns nil if the
+// parameter wasn't passed.
+func getArrayParameter(payload types.AgentRequest, name string) []string {
+	value := strings.TrimSpace(getParameter(payload, name))
+	if value == "" {
+		return nil
+	}
+
+	values := []string{}
+	for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// parseTimeParameter accepts UNIX milliseconds, RFC 3339 timestamps, or dates (e.g. `2025-06-30`, midnight UTC) and
+// returns UNIX milliseconds. An empty value is 0.
+func parseTimeParameter(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return millis, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unrecognized time %q", value)
+}
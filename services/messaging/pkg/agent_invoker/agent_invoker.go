@@ -0,0 +1,207 @@
+package agent_invoker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation_lock"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/delivery_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/rate_limit_service"
+	"github.com/rs/zerolog"
+)
+
+// CatchUpLaterNotice is sent to a conversation the first time it's turned away by the rate limiter.
+const CatchUpLaterNotice = "I'm getting a lot of messages right now, so I'll catch up on this conversation a bit later."
+
+// runLease is how long a run holds the conversation's lock. Runs are cut off by the worker's timeout (5 minutes), so
+// the lease outlives any run and only frees itself when a run died without releasing it.
+const runLease = 6 * time.Minute
+
+// ErrConversationBusy means another run is working on the conversation; the caller should try again later, when that
+// run is done.
+var ErrConversationBusy = errors.New("another agent run is working on the conversation")
+
+// ErrAgentFailed means the agent was invoked but failed. It may have acted on the input before it did (e.g. created a
+// task), so the run shouldn't be retried.
+var ErrAgentFailed = errors.New("agent run failed")
+
+// UnsentAnswerError means the agent ran but its final answer didn't make it to the conversation. Running the agent
+// again could repeat what it did, so the caller should only retry sending the answer, with SendAnswer.
+type UnsentAnswerError struct {
+	// MessageId is the answer's message, if it was saved before delivering it failed.
+	MessageId string
+	Body      string
+	Err       error
+}
+
+func (e *UnsentAnswerError) Error() string {
+	return "failed to send final answer: " + e.Err.Error()
+}
+
+func (e *UnsentAnswerError) Unwrap() error {
+	return e.Err
+}
+
+// DeferredError means the conversation is over its limit and the rate limit policy defers the run; the caller should
+// try it again at RetryAt.
+type DeferredError struct {
+	RetryAt time.Time
+}
+
+func (e *DeferredError) Error() string {
+	return "agent run deferred until " + e.RetryAt.UTC().Format(time.RFC3339)
+}
+
+// Invoker runs the agent for a conversation and sends its final answer, when it has one, to the conversation.
+type Invoker struct {
+	agentService     agent_service.AgentService
+	deliveryService  *delivery_service.DeliveryService
+	lock             conversation_lock.Lock
+	rateLimitService *rate_limit_service.RateLimitService
+	repo             message_repository.MessageRepository
+}
+
+func NewInvoker(
+	agentService agent_service.AgentService,
+	deliveryService *delivery_service.DeliveryService,
+	lock conversation_lock.Lock,
+	rateLimitService *rate_limit_service.RateLimitService,
+	repo message_repository.MessageRepository,
+) *Invoker {
+	return &Invoker{
+		agentService:     agentService,
+		deliveryService:  deliveryService,
+		lock:             lock,
+		rateLimitService: rateLimitService,
+		repo:             repo,
+	}
+}
+
+// NewMessagesInput is what we tell the agent when participants send messages to the conversation.
+func NewMessagesInput(conversationId string, messageIds []string) string {
+	return "New messages were received for the conversation between these numbers: [" + strings.Join(conversation.PhoneNumbers(conversationId), ",") + "]. " +
+		"The new message IDs are: [" + strings.Join(messageIds, ",") + "]"
+}
+
+// Invoke returns the agent's result along with the message its final answer was sent as (nil if it had nothing to
+// say). The result is nil if the conversation is over its rate limit or budget; a *DeferredError is returned if the
+// policy defers rather than drops the run. It returns ErrConversationBusy if another run holds the conversation's
+// lock; overlapping runs would each act on the same history (e.g. both creating the same task). Errors from after the
+// agent was invoked are ErrAgentFailed or an *UnsentAnswerError; any other error means the agent didn't run.
+func (i *Invoker) Invoke(ctx context.Context, conversationId, input string) (*agent_service.InvokeResult, *message_repository.Message, error) {
+	logger := zerolog.Ctx(ctx)
+
+	lease, err := i.lock.Acquire(ctx, conversationId, runLease)
+	if errors.Is(err, conversation_lock.ErrHeld) {
+		return nil, nil, ErrConversationBusy
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire conversation lock: %w", err)
+	}
+	defer func() {
+		if err := i.lock.Release(ctx, lease); err != nil {
+			logger.Error().Err(err).Str("conversation_id", conversationId).Msg("failed to release conversation lock")
+		}
+	}()
+
+	decision, err := i.rateLimitService.Allow(ctx, conversationId, time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	if !decision.Allowed {
+		logger.Warn().Str("conversation_id", conversationId).Str("reason", decision.Reason).Msg("over the limit, not invoking agent")
+		if decision.SendNotice {
+			if _, err := i.send(ctx, conversationId, CatchUpLaterNotice); err != nil {
+				return nil, nil, fmt.Errorf("failed to send catch up notice: %w", err)
+			}
+		}
+		if !decision.RetryAt.IsZero() {
+			return nil, nil, &DeferredError{RetryAt: decision.RetryAt}
+		}
+		return nil, nil, nil
+	}
+
+	if !decision.CatchUpSince.IsZero() {
+		input += "\n\nSome earlier messages weren't looked at because the conversation was over its limit; catch up on everything since " + decision.CatchUpSince.UTC().Format(time.RFC3339) + "."
+	}
+
+	result, err := i.agentService.InvokeAgent(ctx, conversationId, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrAgentFailed, err)
+	}
+
+	if result.FinalText == "" {
+		return result, nil, nil
+	}
+
+	message, err := i.sendAnswer(ctx, conversationId, "", result.FinalText)
+	if err != nil {
+		return result, nil, err
+	}
+
+	logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("sent agent's final answer")
+
+	return result, message, nil
+}
+
+func (i *Invoker) send(ctx context.Context, conversationId, body string) (*message_repository.Message, error) {
+	message, err := i.repo.CreateMessage(conversationId, message_repository.FromAssistant, body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	message, err = i.deliveryService.Deliver(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver message: %w", err)
+	}
+
+	return message, nil
+}
+
+// SendAnswer sends a final answer that Invoke returned an *UnsentAnswerError for; messageId is the error's. Like
+// Invoke, it returns an *UnsentAnswerError if sending fails again.
+func (i *Invoker) SendAnswer(ctx context.Context, conversationId, messageId, body string) (*message_repository.Message, error) {
+	message, err := i.sendAnswer(ctx, conversationId, messageId, body)
+	if err != nil {
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("sent agent's final answer")
+
+	return message, nil
+}
+
+// sendAnswer saves the answer as a message, unless it already was, and delivers it. A message that already has
+// deliveries was sent by an earlier attempt and isn't sent again.
+func (i *Invoker) sendAnswer(ctx context.Context, conversationId, messageId, body string) (*message_repository.Message, error) {
+	var message *message_repository.Message
+	var err error
+	if messageId == "" {
+		message, err = i.repo.CreateMessage(conversationId, message_repository.FromAssistant, body, nil)
+		if err != nil {
+			return nil, &UnsentAnswerError{Body: body, Err: fmt.Errorf("failed to create message: %w", err)}
+		}
+	} else {
+		message, err = i.repo.GetMessage(messageId)
+		if err != nil {
+			return nil, &UnsentAnswerError{MessageId: messageId, Body: body, Err: fmt.Errorf("failed to get message: %w", err)}
+		}
+		if len(message.Deliveries) > 0 {
+			return message, nil
+		}
+	}
+
+	delivered, err := i.deliveryService.Deliver(ctx, message)
+	if err != nil {
+		return nil, &UnsentAnswerError{MessageId: message.Id, Body: body, Err: fmt.Errorf("failed to deliver message: %w", err)}
+	}
+
+	return delivered, nil
+}
@@ -2,26 +2,35 @@ package agent_service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_trace"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/session_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/trace_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_service"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	awsTypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
 
-	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 )
 
 type Aws struct {
-	agentAliasId string
-	agentId      string
-	bedrockAgent *bedrockagentruntime.Client
+	agentAliasId  string
+	agentId       string
+	bedrockAgent  *bedrockagentruntime.Client
+	dispatcher    ActionDispatcher
+	sessionPolicy SessionPolicy
+	sessionRepo   session_repository.SessionRepository
+	traceRepo     trace_repository.TraceRepository
+	usageService  *usage_service.UsageService
 }
 
-func NewAws(ctx context.Context, agentAliasId string, agentId string) (AgentService, error) {
+// dispatcher runs the actions of action groups that return control to us; it can be nil if none of them do.
+func NewAws(ctx context.Context, agentAliasId string, agentId string, dispatcher ActionDispatcher, sessionRepo session_repository.SessionRepository, sessionPolicy SessionPolicy, traceRepo trace_repository.TraceRepository, usageService *usage_service.UsageService) (AgentService, error) {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load SDK config: %v", err)
@@ -29,19 +38,46 @@ func NewAws(ctx context.Context, agentAliasId string, agentId string) (AgentServ
 
 	bedrockAgent := bedrockagentruntime.NewFromConfig(cfg)
 	return &Aws{
-		agentAliasId: agentAliasId,
-		agentId:      agentId,
-		bedrockAgent: bedrockAgent,
+		agentAliasId:  agentAliasId,
+		agentId:       agentId,
+		bedrockAgent:  bedrockAgent,
+		dispatcher:    dispatcher,
+		sessionPolicy: sessionPolicy,
+		sessionRepo:   sessionRepo,
+		traceRepo:     traceRepo,
+		usageService:  usageService,
 	}, nil
 }
 
-func (a *Aws) InvokeAgent(ctx context.Context, input string) error {
+func (a *Aws) InvokeAgent(ctx context.Context, conversationId, input string) (*InvokeResult, error) {
+	session, err := nextSession(a.sessionRepo, a.sessionPolicy, conversationId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := newTraceRecorder(ctx, a.traceRepo, conversationId, session.SessionId, input)
+	result, err := a.invoke(ctx, conversationId, input, session, recorder)
+	recorder.finish(ctx, result, err)
+
+	// We pay for the tokens whether or not the invocation worked out.
+	usage := recorder.modelUsage()
+	if recordErr := a.usageService.Record(ctx, conversationId, time.Now(), usage); recordErr != nil {
+		zerolog.Ctx(ctx).Error().Err(recordErr).Str("conversationId", conversationId).Msg("failed to record usage")
+	}
+	if result != nil {
+		result.Usage = usage
+	}
+
+	return result, err
+}
+
+func (a *Aws) invoke(ctx context.Context, conversationId, input string, session *session_repository.Session, recorder *traceRecorder) (*InvokeResult, error) {
 	logger := zerolog.Ctx(ctx)
 
 	streamingConfigurations := awsTypes.StreamingConfigurations{
 		StreamFinalResponse: true,
 	}
-	sessionId := uuid.New().String()
+	sessionId := session.SessionId
 	invokeInput := &bedrockagentruntime.InvokeAgentInput{
 		AgentAliasId:            &a.agentAliasId,
 		AgentId:                 &a.agentId,
@@ -55,13 +91,70 @@ func (a *Aws) InvokeAgent(ctx context.Context, input string) error {
 		Str("agentAliasId", a.agentAliasId).
 		Str("agentId", a.agentId).
 		Str("sessionId", sessionId).
+		Int("sessionInvocations", session.Invocations).
+		Str("conversationId", conversationId).
+		Str("invocationId", recorder.invocationId()).
 		Str("input", input).
 		Interface("streamingConfig", streamingConfigurations).
 		Msg("invoking agent")
 
+	result := &InvokeResult{
+		InvocationId: recorder.invocationId(),
+		SessionId:    sessionId,
+		Citations:    []*Citation{},
+		ActionCalls:  []*ActionCall{},
+	}
+	var finalText strings.Builder
+	for turn := 0; ; turn++ {
+		if turn > maxReturnControlTurns {
+			return nil, fmt.Errorf("agent didn't finish after %d return control turns", maxReturnControlTurns)
+		}
+
+		returnControl, err := a.processStream(ctx, invokeInput, recorder, result, &finalText)
+		if err != nil {
+			return nil, err
+		}
+		if returnControl == nil {
+			break
+		}
+
+		// The agent wants us to run its actions; we hand it the results and it carries on from there.
+		invocationResults, err := a.runReturnControl(ctx, sessionId, returnControl, result)
+		if err != nil {
+			return nil, err
+		}
+		invokeInput = &bedrockagentruntime.InvokeAgentInput{
+			AgentAliasId:            &a.agentAliasId,
+			AgentId:                 &a.agentId,
+			SessionId:               &sessionId,
+			EnableTrace:             aws.Bool(true),
+			StreamingConfigurations: &streamingConfigurations,
+			SessionState: &awsTypes.SessionState{
+				InvocationId:                   returnControl.InvocationId,
+				ReturnControlInvocationResults: invocationResults,
+			},
+		}
+	}
+
+	result.FinalText = strings.TrimSpace(finalText.String())
+
+	logger.Info().
+		Str("finalText", result.FinalText).
+		Int("citations", len(result.Citations)).
+		Interface("actionCalls", result.ActionCalls).
+		Msg("agent finished")
+
+	return result, nil
+}
+
+// processStream invokes the agent and adds what it streams back to the result. It returns the agent's return control
+// payload if it handed control back to us instead of finishing.
+func (a *Aws) processStream(ctx context.Context, invokeInput *bedrockagentruntime.InvokeAgentInput, recorder *traceRecorder, result *InvokeResult, finalText *strings.Builder) (*awsTypes.ReturnControlPayload, error) {
+	logger := zerolog.Ctx(ctx)
+
 	invokeOutput, err := a.bedrockAgent.InvokeAgent(ctx, invokeInput)
 	if err != nil {
-		return fmt.Errorf("failed to invoke agent: %w", err)
+		return nil, fmt.Errorf("failed to invoke agent: %w", err)
 	}
 
 	logger.Info().
@@ -73,37 +166,40 @@ func (a *Aws) InvokeAgent(ctx context.Context, input string) error {
 
 	logger.Info().Msg("starting to process stream events")
 
+	var returnControl *awsTypes.ReturnControlPayload
 	for event := range stream.Events() {
 		switch e := event.(type) {
 		case *awsTypes.ResponseStreamMemberChunk:
 			logger.Debug().Str("chunk", string(e.Value.Bytes)).Msg("received chunk")
+			finalText.Write(e.Value.Bytes)
+			result.Citations = append(result.Citations, citationsFromAttribution(e.Value.Attribution)...)
 		case *awsTypes.ResponseStreamMemberTrace:
-			traceBytes, err := json.Marshal(e.Value)
-			if err != nil {
-				logger.Error().Err(err).Msg("failed to marshal trace")
-			}
-			var trace types.AgentTrace
-			err = json.Unmarshal(traceBytes, &trace)
-			if err != nil {
-				logger.Error().Err(err).Msg("failed to unmarshal trace")
-			}
-
-			var traceText types.AgentTraceTextFromJson
-			err = json.Unmarshal([]byte(trace.Trace.Value.Value.Text), &traceText)
-			if err != nil {
-				logger.Error().Err(err).Msg("failed to unmarshal trace text")
+			trace := agent_trace.FromTracePart(e.Value)
+			recorder.add(trace)
+			if actionCall := actionCallFromTrace(trace); actionCall != nil {
+				result.ActionCalls = append(result.ActionCalls, actionCall)
 			}
 
-			for _, message := range traceText.Messages {
-				logger.Debug().Str("message", message.Content).Str("role", message.Role).Msg("trace message")
+			if modelInvocationInput := trace.ModelInvocationInput(); modelInvocationInput != nil {
+				for _, message := range modelInvocationInput.PromptMessages() {
+					logger.Debug().Str("message", message.Content).Str("role", message.Role).Msg("trace message")
+				}
 			}
 
 		case *awsTypes.ResponseStreamMemberReturnControl:
 			logger.Debug().Interface("returnControl", e.Value).Msg("received return control event")
+			returnControl = &e.Value
 		default:
 			logger.Warn().Interface("event", event).Msg("received unknown event type")
 		}
 	}
 
-	return nil
+	if err := stream.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close stream: %w", err)
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return returnControl, nil
 }
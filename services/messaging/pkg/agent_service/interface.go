@@ -3,5 +3,6 @@ package agent_service
 import "context"
 
 type AgentService interface {
-	InvokeAgent(ctx context.Context, input string) error
+	// InvokeAgent runs the agent in the conversation's session, so it keeps its working memory between messages.
+	InvokeAgent(ctx context.Context, conversationId, input string) (*InvokeResult, error)
 }
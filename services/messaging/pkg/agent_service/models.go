@@ -0,0 +1,29 @@
+package agent_service
+
+import "github.com/anthonywittig/text-agent/services/messaging/pkg/usage_service"
+
+// InvokeResult is what the agent did and said during one invocation.
+type InvokeResult struct {
+	// InvocationId is the invocation's ID in the trace repository; it's empty if we failed to record it.
+	InvocationId string `json:"invocation_id"`
+	SessionId    string `json:"session_id"`
+	// FinalText is the agent's final answer; it's empty when the agent decided there's nothing to say.
+	FinalText   string        `json:"final_text"`
+	Citations   []*Citation   `json:"citations"`
+	ActionCalls []*ActionCall `json:"action_calls"`
+	// Usage is the tokens the invocation used, by model.
+	Usage []*usage_service.ModelUsage `json:"usage"`
+}
+
+// Citation ties part of the final answer to where it came from (e.g. a knowledge base document).
+type Citation struct {
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"`
+}
+
+// ActionCall is an action group function the agent invoked while working on its answer.
+type ActionCall struct {
+	ActionGroup string            `json:"action_group"`
+	Function    string            `json:"function"`
+	Parameters  map[string]string `json:"parameters"`
+}
@@ -0,0 +1,74 @@
+package agent_service
+
+import (
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_trace"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsTypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// actionCallFromTrace returns the action group call a trace describes, or nil if it isn't one.
+func actionCallFromTrace(trace *agent_trace.TracePart) *ActionCall {
+	orchestration := trace.Trace.OrchestrationTrace
+	if orchestration == nil || orchestration.InvocationInput == nil || orchestration.InvocationInput.ActionGroupInvocationInput == nil {
+		return nil
+	}
+
+	input := orchestration.InvocationInput.ActionGroupInvocationInput
+	if input.ExecutionType == string(awsTypes.ExecutionTypeReturnControl) {
+		// These are recorded when we run them.
+		return nil
+	}
+
+	parameters := map[string]string{}
+	for _, parameter := range input.Parameters {
+		parameters[parameter.Name] = parameter.Value
+	}
+
+	return &ActionCall{
+		ActionGroup: input.ActionGroupName,
+		Function:    input.Function,
+		Parameters:  parameters,
+	}
+}
+
+func citationsFromAttribution(attribution *awsTypes.Attribution) []*Citation {
+	if attribution == nil {
+		return nil
+	}
+
+	citations := []*Citation{}
+	for _, c := range attribution.Citations {
+		citation := &Citation{Sources: []string{}}
+		if c.GeneratedResponsePart != nil && c.GeneratedResponsePart.TextResponsePart != nil {
+			citation.Text = aws.ToString(c.GeneratedResponsePart.TextResponsePart.Text)
+		}
+		for _, reference := range c.RetrievedReferences {
+			if source := referenceSource(reference); source != "" {
+				citation.Sources = append(citation.Sources, source)
+			}
+		}
+		citations = append(citations, citation)
+	}
+
+	return citations
+}
+
+// referenceSource is a human readable pointer to where a reference came from; we only care about the locations our
+// knowledge bases could plausibly use.
+func referenceSource(reference awsTypes.RetrievedReference) string {
+	location := reference.Location
+	if location == nil {
+		return ""
+	}
+
+	switch {
+	case location.S3Location != nil:
+		return aws.ToString(location.S3Location.Uri)
+	case location.WebLocation != nil:
+		return aws.ToString(location.WebLocation.Url)
+	case location.CustomDocumentLocation != nil:
+		return aws.ToString(location.CustomDocumentLocation.Id)
+	default:
+		return string(location.Type)
+	}
+}
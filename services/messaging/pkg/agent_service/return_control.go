@@ -0,0 +1,126 @@
+package agent_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsTypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/rs/zerolog"
+)
+
+// Each turn is a round trip to Bedrock, and an agent that keeps asking for actions is stuck rather than busy.
+const maxReturnControlTurns = 20
+
+// ActionDispatcher runs an action group function in-process, with the same request and response Bedrock would send
+// to and expect from the action group's Lambda.
+type ActionDispatcher interface {
+	Dispatch(ctx context.Context, request types.AgentRequest) (types.AgentResponse, error)
+}
+
+// runReturnControl runs the actions the agent handed back to us and returns their results for the next turn.
+func (a *Aws) runReturnControl(ctx context.Context, sessionId string, returnControl *awsTypes.ReturnControlPayload, result *InvokeResult) ([]awsTypes.InvocationResultMember, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if a.dispatcher == nil {
+		return nil, fmt.Errorf("agent returned control but there's no action dispatcher")
+	}
+
+	invocationResults := []awsTypes.InvocationResultMember{}
+	for _, invocationInput := range returnControl.InvocationInputs {
+		functionInput, ok := invocationInput.(*awsTypes.InvocationInputMemberMemberFunctionInvocationInput)
+		if !ok {
+			// We only define function action groups, not API schemas.
+			return nil, fmt.Errorf("unsupported return control invocation input: %T", invocationInput)
+		}
+
+		request := a.agentRequest(sessionId, functionInput.Value)
+		result.ActionCalls = append(result.ActionCalls, &ActionCall{
+			ActionGroup: request.ActionGroup,
+			Function:    request.Function,
+			Parameters:  functionParameters(functionInput.Value.Parameters),
+		})
+
+		logger.Info().Str("actionGroup", request.ActionGroup).Str("function", request.Function).Msg("dispatching returned control")
+
+		response, err := a.dispatcher.Dispatch(ctx, request)
+		if err != nil {
+			// The agent can try something else or tell the conversation it couldn't; failing the invocation would
+			// lose its whole turn, along with any actions that already ran.
+			logger.Error().Err(err).Str("actionGroup", request.ActionGroup).Str("function", request.Function).Msg("failed to dispatch returned control")
+			response = dispatchFailureResponse(request, err)
+		}
+
+		invocationResults = append(invocationResults, &awsTypes.InvocationResultMemberMemberFunctionResult{
+			Value: awsTypes.FunctionResult{
+				ActionGroup:   functionInput.Value.ActionGroup,
+				Function:      functionInput.Value.Function,
+				ResponseState: awsTypes.ResponseState(response.Response.FunctionResponse.ResponseState),
+				ResponseBody: map[string]awsTypes.ContentBody{
+					"TEXT": {Body: aws.String(response.Response.FunctionResponse.ResponseBody.ContentType.Body)},
+				},
+			},
+		})
+	}
+
+	return invocationResults, nil
+}
+
+// dispatchFailureResponse is what the action group's Lambda would have answered if it had failed the same way.
+func dispatchFailureResponse(request types.AgentRequest, err error) types.AgentResponse {
+	body, _ := json.Marshal(map[string]string{"message": err.Error()})
+
+	return types.AgentResponse{
+		MessageVersion: "1.0",
+		Response: types.AgentResponseResponse{
+			ActionGroup: request.ActionGroup,
+			Function:    request.Function,
+			FunctionResponse: types.AgentResponseResponseFunctionResponse{
+				ResponseState: string(awsTypes.ResponseStateFailure),
+				ResponseBody: types.AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: types.AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(body),
+					},
+				},
+			},
+		},
+	}
+}
+
+// agentRequest builds the request Bedrock would have sent the action group's Lambda. The agent is filled in so
+// handlers can tell the agent made the call, just like they can when it comes through Lambda.
+func (a *Aws) agentRequest(sessionId string, input awsTypes.FunctionInvocationInput) types.AgentRequest {
+	request := types.AgentRequest{
+		MessageVersion: "1.0",
+		Function:       aws.ToString(input.Function),
+		SessionId:      sessionId,
+		ActionGroup:    aws.ToString(input.ActionGroup),
+	}
+	request.Agent.Id = a.agentId
+	request.Agent.Name = a.agentId
+	request.Agent.Alias = a.agentAliasId
+
+	for _, parameter := range input.Parameters {
+		request.Parameters = append(request.Parameters, struct {
+			Name  string `json:"name"`
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		}{
+			Name:  aws.ToString(parameter.Name),
+			Type:  aws.ToString(parameter.Type),
+			Value: aws.ToString(parameter.Value),
+		})
+	}
+
+	return request
+}
+
+func functionParameters(parameters []awsTypes.FunctionParameter) map[string]string {
+	m := map[string]string{}
+	for _, parameter := range parameters {
+		m[aws.ToString(parameter.Name)] = aws.ToString(parameter.Value)
+	}
+	return m
+}
@@ -0,0 +1,86 @@
+package agent_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsTypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+type fakeDispatcher struct {
+	errs map[string]error
+}
+
+func (d *fakeDispatcher) Dispatch(ctx context.Context, request types.AgentRequest) (types.AgentResponse, error) {
+	if err := d.errs[request.Function]; err != nil {
+		return types.AgentResponse{}, err
+	}
+
+	response := types.AgentResponse{MessageVersion: "1.0"}
+	response.Response.ActionGroup = request.ActionGroup
+	response.Response.Function = request.Function
+	response.Response.FunctionResponse.ResponseState = "REPROMPT"
+	response.Response.FunctionResponse.ResponseBody.ContentType.Body = `{"info":"ok"}`
+	return response, nil
+}
+
+func TestRunReturnControl(t *testing.T) {
+	a := &Aws{
+		agentId:    "AGENT",
+		dispatcher: &fakeDispatcher{errs: map[string]error{"task_tracking_update": errors.New(`task "42" not found`)}},
+	}
+
+	returnControl := &awsTypes.ReturnControlPayload{
+		InvocationInputs: []awsTypes.InvocationInputMember{
+			&awsTypes.InvocationInputMemberMemberFunctionInvocationInput{Value: awsTypes.FunctionInvocationInput{
+				ActionGroup: aws.String("task_tracking"),
+				Function:    aws.String("task_tracking_update"),
+				Parameters:  []awsTypes.FunctionParameter{{Name: aws.String("id"), Type: aws.String("string"), Value: aws.String("42")}},
+			}},
+			&awsTypes.InvocationInputMemberMemberFunctionInvocationInput{Value: awsTypes.FunctionInvocationInput{
+				ActionGroup: aws.String("task_tracking"),
+				Function:    aws.String("task_tracking_list"),
+			}},
+		},
+	}
+
+	result := &InvokeResult{}
+	results, err := a.runReturnControl(context.Background(), "session", returnControl, result)
+	if err != nil {
+		t.Fatalf("runReturnControl() error = %v", err)
+	}
+
+	if len(result.ActionCalls) != 2 || result.ActionCalls[0].Parameters["id"] != "42" {
+		t.Errorf("action calls = %+v", result.ActionCalls)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want 2", len(results))
+	}
+
+	tests := []struct {
+		function  string
+		wantState awsTypes.ResponseState
+		wantBody  string
+	}{
+		{"task_tracking_update", awsTypes.ResponseStateFailure, `{"message":"task \"42\" not found"}`},
+		{"task_tracking_list", awsTypes.ResponseState("REPROMPT"), `{"info":"ok"}`},
+	}
+	for i, tt := range tests {
+		functionResult, ok := results[i].(*awsTypes.InvocationResultMemberMemberFunctionResult)
+		if !ok {
+			t.Fatalf("results[%d] = %T", i, results[i])
+		}
+		if got := aws.ToString(functionResult.Value.Function); got != tt.function {
+			t.Errorf("results[%d] function = %q, want %q", i, got, tt.function)
+		}
+		if functionResult.Value.ResponseState != tt.wantState {
+			t.Errorf("results[%d] state = %q, want %q", i, functionResult.Value.ResponseState, tt.wantState)
+		}
+		if got := aws.ToString(functionResult.Value.ResponseBody["TEXT"].Body); got != tt.wantBody {
+			t.Errorf("results[%d] body = %s, want %s", i, got, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package agent_service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/session_repository"
+	"github.com/google/uuid"
+)
+
+// SessionPolicy decides when a conversation gets a fresh Bedrock session. Bedrock keeps the agent's working memory
+// per session, so we reuse one for as long as it's useful and roll over before it gets stale or too long.
+type SessionPolicy struct {
+	// MaxInvocations rolls the session over after this many invocations; 0 means never.
+	MaxInvocations int
+	// IdleTimeout rolls the session over when it hasn't been used for this long; 0 means never. Bedrock also drops
+	// sessions after the agent's idle session TTL, in which case reusing the ID just starts with an empty memory.
+	IdleTimeout time.Duration
+}
+
+var DefaultSessionPolicy = SessionPolicy{
+	MaxInvocations: 50,
+	IdleTimeout:    time.Hour,
+}
+
+// ParseSessionPolicy builds a policy from its string settings (e.g. environment variables); empty settings keep the
+// default. idleTimeout is a duration like "30m".
+func ParseSessionPolicy(maxInvocations, idleTimeout string) (SessionPolicy, error) {
+	policy := DefaultSessionPolicy
+
+	if maxInvocations != "" {
+		n, err := strconv.Atoi(maxInvocations)
+		if err != nil || n < 0 {
+			return SessionPolicy{}, fmt.Errorf("invalid max invocations: %s", maxInvocations)
+		}
+		policy.MaxInvocations = n
+	}
+
+	if idleTimeout != "" {
+		d, err := time.ParseDuration(idleTimeout)
+		if err != nil || d < 0 {
+			return SessionPolicy{}, fmt.Errorf("invalid idle timeout: %s", idleTimeout)
+		}
+		policy.IdleTimeout = d
+	}
+
+	return policy, nil
+}
+
+// maxSessionConflictRetries bounds how often nextSession rereads a session another invocation saved first.
+const maxSessionConflictRetries = 5
+
+// nextSession returns the session to use for the next invocation and records that it's being used.
+func nextSession(sessionRepo session_repository.SessionRepository, policy SessionPolicy, conversationId string, now time.Time) (*session_repository.Session, error) {
+	for attempt := 0; attempt < maxSessionConflictRetries; attempt++ {
+		session, err := sessionRepo.GetSession(conversationId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session == nil || policy.shouldRollOver(session, now) {
+			// The version carries on so the save can't clobber a session another invocation just saved.
+			version := int64(0)
+			if session != nil {
+				version = session.Version
+			}
+			session = &session_repository.Session{
+				ConversationId: conversationId,
+				SessionId:      uuid.New().String(),
+				CreatedAt:      now.UnixMilli(),
+				Version:        version,
+			}
+		}
+
+		session.Invocations++
+		session.LastUsedAt = now.UnixMilli()
+		err = sessionRepo.SaveSession(session)
+		if errors.Is(err, session_repository.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save session: %w", err)
+		}
+
+		return session, nil
+	}
+
+	return nil, fmt.Errorf("failed to update session after %d attempts", maxSessionConflictRetries)
+}
+
+func (p SessionPolicy) shouldRollOver(session *session_repository.Session, now time.Time) bool {
+	if p.MaxInvocations > 0 && session.Invocations >= p.MaxInvocations {
+		return true
+	}
+	if p.IdleTimeout > 0 && now.Sub(time.UnixMilli(session.LastUsedAt)) > p.IdleTimeout {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package agent_service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/session_repository"
+)
+
+// fakeSessionRepository keeps versioned sessions like the Dynamo repository. conflicts is how many saves fail with
+// ErrConflict, each one as if another invocation had used the session in between.
+type fakeSessionRepository struct {
+	sessions  map[string]session_repository.Session
+	conflicts int
+	saves     int
+}
+
+func (r *fakeSessionRepository) GetSession(conversationId string) (*session_repository.Session, error) {
+	session, ok := r.sessions[conversationId]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (r *fakeSessionRepository) SaveSession(session *session_repository.Session) error {
+	r.saves++
+	if r.conflicts > 0 {
+		r.conflicts--
+		stored := r.sessions[session.ConversationId]
+		stored.Invocations++
+		stored.Version++
+		r.sessions[session.ConversationId] = stored
+		return session_repository.ErrConflict
+	}
+	if r.sessions[session.ConversationId].Version != session.Version {
+		return session_repository.ErrConflict
+	}
+	session.Version++
+	r.sessions[session.ConversationId] = *session
+	return nil
+}
+
+func TestNextSession(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	now := time.Date(2025, 8, 22, 12, 0, 0, 0, time.UTC)
+	policy := SessionPolicy{MaxInvocations: 3, IdleTimeout: time.Hour}
+
+	existing := session_repository.Session{
+		ConversationId: conversationId,
+		SessionId:      "existing",
+		Invocations:    1,
+		CreatedAt:      now.Add(-2 * time.Hour).UnixMilli(),
+		LastUsedAt:     now.Add(-time.Minute).UnixMilli(),
+		Version:        1,
+	}
+
+	tests := []struct {
+		name            string
+		stored          *session_repository.Session
+		conflicts       int
+		wantExisting    bool
+		wantInvocations int
+		wantSaves       int
+		wantErr         bool
+	}{
+		{name: "new", wantInvocations: 1, wantSaves: 1},
+		{name: "reused", stored: &existing, wantExisting: true, wantInvocations: 2, wantSaves: 1},
+		{
+			name:   "idle",
+			stored: &session_repository.Session{ConversationId: conversationId, SessionId: "existing", Invocations: 1, LastUsedAt: now.Add(-2 * time.Hour).UnixMilli(), Version: 4},
+			// A fresh session, saved over the old one's version.
+			wantInvocations: 1,
+			wantSaves:       1,
+		},
+		{
+			name:            "too many invocations",
+			stored:          &session_repository.Session{ConversationId: conversationId, SessionId: "existing", Invocations: 3, LastUsedAt: now.UnixMilli(), Version: 2},
+			wantInvocations: 1,
+			wantSaves:       1,
+		},
+		{
+			name:      "conflict",
+			stored:    &existing,
+			conflicts: 1,
+			// The other invocation's use is counted too.
+			wantExisting:    true,
+			wantInvocations: 3,
+			wantSaves:       2,
+		},
+		{
+			name:      "conflict rolls over",
+			stored:    &existing,
+			conflicts: 2,
+			// The other invocations used it up, so this one starts a new session.
+			wantInvocations: 1,
+			wantSaves:       3,
+		},
+		{name: "too many conflicts", stored: &existing, conflicts: maxSessionConflictRetries, wantSaves: maxSessionConflictRetries, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessionRepository{sessions: map[string]session_repository.Session{}, conflicts: tt.conflicts}
+			if tt.stored != nil {
+				repo.sessions[conversationId] = *tt.stored
+			}
+
+			session, err := nextSession(repo, policy, conversationId, now)
+			if repo.saves != tt.wantSaves {
+				t.Errorf("saves = %d, want %d", repo.saves, tt.wantSaves)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("nextSession() = %+v, want an error", session)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("nextSession() error = %v", err)
+			}
+
+			if isExisting := session.SessionId == "existing"; isExisting != tt.wantExisting {
+				t.Errorf("session ID = %q, want existing %v", session.SessionId, tt.wantExisting)
+			}
+			if session.Invocations != tt.wantInvocations {
+				t.Errorf("invocations = %d, want %d", session.Invocations, tt.wantInvocations)
+			}
+			if session.LastUsedAt != now.UnixMilli() {
+				t.Errorf("last used at = %d, want %d", session.LastUsedAt, now.UnixMilli())
+			}
+			if stored := repo.sessions[conversationId]; stored != *session {
+				t.Errorf("stored session = %+v, want %+v", stored, *session)
+			}
+		})
+	}
+}
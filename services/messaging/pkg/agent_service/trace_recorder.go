@@ -0,0 +1,253 @@
+package agent_service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_trace"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/trace_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_service"
+	"github.com/rs/zerolog"
+)
+
+// traceRecorder stores an invocation's trace and tallies the tokens it reports. The trace is for debugging, so failing
+// to store it is logged rather than failing the invocation.
+type traceRecorder struct {
+	repo           trace_repository.TraceRepository
+	conversationId string
+	invocation     *trace_repository.Invocation
+	events         []*trace_repository.TraceEvent
+	// The model's output doesn't say which model it came from, but it shares a trace ID with the model's input.
+	models    map[string]string
+	lastModel string
+	usage     map[string]*usage_service.ModelUsage
+}
+
+func newTraceRecorder(ctx context.Context, repo trace_repository.TraceRepository, conversationId, sessionId, input string) *traceRecorder {
+	logger := zerolog.Ctx(ctx)
+
+	invocation, err := repo.CreateInvocation(conversationId, sessionId, input)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to create trace invocation")
+	}
+
+	return &traceRecorder{
+		repo:           repo,
+		conversationId: conversationId,
+		invocation:     invocation,
+		models:         map[string]string{},
+		usage:          map[string]*usage_service.ModelUsage{},
+	}
+}
+
+func (r *traceRecorder) invocationId() string {
+	if r.invocation == nil {
+		return ""
+	}
+	return r.invocation.Id
+}
+
+func (r *traceRecorder) add(trace *agent_trace.TracePart) {
+	r.countUsage(trace)
+
+	if r.invocation == nil {
+		return
+	}
+
+	event := traceEvent(trace)
+	event.InvocationId = r.invocation.Id
+	event.Sequence = len(r.events)
+	event.ConversationId = r.conversationId
+	event.At = time.Now().UnixMilli()
+	r.events = append(r.events, event)
+}
+
+func (r *traceRecorder) finish(ctx context.Context, result *InvokeResult, invokeErr error) {
+	logger := zerolog.Ctx(ctx)
+
+	if r.invocation == nil {
+		return
+	}
+
+	if err := r.repo.AddEvents(r.events); err != nil {
+		logger.Error().Err(err).Str("invocationId", r.invocation.Id).Msg("failed to store trace events")
+	}
+
+	finalText := ""
+	if result != nil {
+		finalText = result.FinalText
+	}
+	errorMessage := ""
+	if invokeErr != nil {
+		errorMessage = invokeErr.Error()
+	}
+	if _, err := r.repo.FinishInvocation(r.invocation.Id, finalText, errorMessage); err != nil {
+		logger.Error().Err(err).Str("invocationId", r.invocation.Id).Msg("failed to finish trace invocation")
+	}
+}
+
+func (r *traceRecorder) countUsage(trace *agent_trace.TracePart) {
+	if input := trace.ModelInvocationInput(); input != nil && input.FoundationModel != "" {
+		r.models[input.TraceId] = input.FoundationModel
+		r.lastModel = input.FoundationModel
+		return
+	}
+
+	usage := trace.Usage()
+	if usage == nil {
+		return
+	}
+
+	model, ok := r.models[trace.TraceId()]
+	if !ok {
+		model = r.lastModel
+	}
+
+	modelUsage, ok := r.usage[model]
+	if !ok {
+		modelUsage = &usage_service.ModelUsage{Model: model}
+		r.usage[model] = modelUsage
+	}
+	modelUsage.InputTokens += int64(usage.InputTokens)
+	modelUsage.OutputTokens += int64(usage.OutputTokens)
+}
+
+// modelUsage is the tokens the invocation used so far, by model.
+func (r *traceRecorder) modelUsage() []*usage_service.ModelUsage {
+	usage := []*usage_service.ModelUsage{}
+	for _, u := range r.usage {
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// traceEvent pulls what we care about out of the trace; the whole trace is kept as raw JSON for the types where the
+// fields don't capture everything.
+func traceEvent(trace *agent_trace.TracePart) *trace_repository.TraceEvent {
+	if input := trace.ModelInvocationInput(); input != nil {
+		messages := []*trace_repository.PromptMessage{}
+		for _, message := range input.PromptMessages() {
+			messages = append(messages, &trace_repository.PromptMessage{Role: message.Role, Content: message.Content})
+		}
+		return &trace_repository.TraceEvent{
+			Type:     trace_repository.TraceEventTypeModelInput,
+			TraceId:  input.TraceId,
+			Model:    input.FoundationModel,
+			Messages: messages,
+		}
+	}
+
+	switch {
+	case trace.Trace.PreProcessingTrace != nil && trace.Trace.PreProcessingTrace.ModelInvocationOutput != nil:
+		output := trace.Trace.PreProcessingTrace.ModelInvocationOutput
+		event := &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeModelOutput,
+			TraceId: output.TraceId,
+			Raw:     rawTrace(trace),
+		}
+		if output.ParsedResponse != nil {
+			event.Rationale = output.ParsedResponse.Rationale
+		}
+		return event
+	case trace.Trace.PostProcessingTrace != nil && trace.Trace.PostProcessingTrace.ModelInvocationOutput != nil:
+		output := trace.Trace.PostProcessingTrace.ModelInvocationOutput
+		event := &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeModelOutput,
+			TraceId: output.TraceId,
+			Raw:     rawTrace(trace),
+		}
+		if output.ParsedResponse != nil {
+			event.Observation = output.ParsedResponse.Text
+		}
+		return event
+	case trace.Trace.OrchestrationTrace != nil:
+		return orchestrationTraceEvent(trace)
+	case trace.Trace.GuardrailTrace != nil:
+		return &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeGuardrail,
+			TraceId: trace.Trace.GuardrailTrace.TraceId,
+			Raw:     rawTrace(trace),
+		}
+	case trace.Trace.FailureTrace != nil:
+		return &trace_repository.TraceEvent{
+			Type:        trace_repository.TraceEventTypeFailure,
+			TraceId:     trace.Trace.FailureTrace.TraceId,
+			Observation: trace.Trace.FailureTrace.FailureReason,
+			Raw:         rawTrace(trace),
+		}
+	default:
+		return &trace_repository.TraceEvent{
+			Type: trace_repository.TraceEventTypeOther,
+			Raw:  rawTrace(trace),
+		}
+	}
+}
+
+func orchestrationTraceEvent(trace *agent_trace.TracePart) *trace_repository.TraceEvent {
+	orchestration := trace.Trace.OrchestrationTrace
+
+	switch {
+	case orchestration.ModelInvocationOutput != nil:
+		event := &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeModelOutput,
+			TraceId: orchestration.ModelInvocationOutput.TraceId,
+		}
+		if orchestration.ModelInvocationOutput.RawResponse != nil {
+			event.Observation = orchestration.ModelInvocationOutput.RawResponse.Content
+		}
+		return event
+	case orchestration.Rationale != nil:
+		return &trace_repository.TraceEvent{
+			Type:      trace_repository.TraceEventTypeRationale,
+			TraceId:   orchestration.Rationale.TraceId,
+			Rationale: orchestration.Rationale.Text,
+		}
+	case orchestration.InvocationInput != nil && orchestration.InvocationInput.ActionGroupInvocationInput != nil:
+		input := orchestration.InvocationInput.ActionGroupInvocationInput
+		parameters := map[string]string{}
+		for _, parameter := range input.Parameters {
+			parameters[parameter.Name] = parameter.Value
+		}
+		return &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeToolCall,
+			TraceId: orchestration.InvocationInput.TraceId,
+			ToolCall: &trace_repository.ToolCall{
+				ActionGroup: input.ActionGroupName,
+				Function:    input.Function,
+				Parameters:  parameters,
+			},
+		}
+	case orchestration.Observation != nil:
+		observation := orchestration.Observation
+		event := &trace_repository.TraceEvent{
+			Type:    trace_repository.TraceEventTypeObservation,
+			TraceId: observation.TraceId,
+		}
+		switch {
+		case observation.ActionGroupInvocationOutput != nil:
+			event.Observation = observation.ActionGroupInvocationOutput.Text
+		case observation.FinalResponse != nil:
+			event.Observation = observation.FinalResponse.Text
+		case observation.RepromptResponse != nil:
+			event.Observation = observation.RepromptResponse.Text
+		default:
+			event.Raw = rawTrace(trace)
+		}
+		return event
+	default:
+		return &trace_repository.TraceEvent{
+			Type: trace_repository.TraceEventTypeOther,
+			Raw:  rawTrace(trace),
+		}
+	}
+}
+
+// rawTrace is in Bedrock's trace JSON, so it can be read back with agent_trace.Parse.
+func rawTrace(trace *agent_trace.TracePart) string {
+	b, err := json.Marshal(trace)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
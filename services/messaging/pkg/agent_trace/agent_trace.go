@@ -0,0 +1,168 @@
+package agent_trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindPreProcessing  Kind = "pre_processing"
+	KindOrchestration  Kind = "orchestration"
+	KindPostProcessing Kind = "post_processing"
+	KindGuardrail      Kind = "guardrail"
+	KindFailure        Kind = "failure"
+	KindUnknown        Kind = "unknown"
+)
+
+// Parse decodes a trace event in Bedrock's JSON form (e.g. from logs or the docs).
+func Parse(data []byte) (*TracePart, error) {
+	var part TracePart
+	if err := json.Unmarshal(data, &part); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trace: %w", err)
+	}
+
+	if part.Kind() == KindUnknown {
+		return nil, fmt.Errorf("unknown trace type")
+	}
+
+	return &part, nil
+}
+
+func (p *TracePart) Kind() Kind {
+	switch {
+	case p.Trace.PreProcessingTrace != nil:
+		return KindPreProcessing
+	case p.Trace.OrchestrationTrace != nil:
+		return KindOrchestration
+	case p.Trace.PostProcessingTrace != nil:
+		return KindPostProcessing
+	case p.Trace.GuardrailTrace != nil:
+		return KindGuardrail
+	case p.Trace.FailureTrace != nil:
+		return KindFailure
+	default:
+		return KindUnknown
+	}
+}
+
+// TraceId ties together the traces of one step (e.g. a model's input and its output).
+func (p *TracePart) TraceId() string {
+	t := p.Trace
+	switch {
+	case t.PreProcessingTrace != nil && t.PreProcessingTrace.ModelInvocationInput != nil:
+		return t.PreProcessingTrace.ModelInvocationInput.TraceId
+	case t.PreProcessingTrace != nil && t.PreProcessingTrace.ModelInvocationOutput != nil:
+		return t.PreProcessingTrace.ModelInvocationOutput.TraceId
+	case t.OrchestrationTrace != nil && t.OrchestrationTrace.ModelInvocationInput != nil:
+		return t.OrchestrationTrace.ModelInvocationInput.TraceId
+	case t.OrchestrationTrace != nil && t.OrchestrationTrace.ModelInvocationOutput != nil:
+		return t.OrchestrationTrace.ModelInvocationOutput.TraceId
+	case t.OrchestrationTrace != nil && t.OrchestrationTrace.Rationale != nil:
+		return t.OrchestrationTrace.Rationale.TraceId
+	case t.OrchestrationTrace != nil && t.OrchestrationTrace.InvocationInput != nil:
+		return t.OrchestrationTrace.InvocationInput.TraceId
+	case t.OrchestrationTrace != nil && t.OrchestrationTrace.Observation != nil:
+		return t.OrchestrationTrace.Observation.TraceId
+	case t.PostProcessingTrace != nil && t.PostProcessingTrace.ModelInvocationInput != nil:
+		return t.PostProcessingTrace.ModelInvocationInput.TraceId
+	case t.PostProcessingTrace != nil && t.PostProcessingTrace.ModelInvocationOutput != nil:
+		return t.PostProcessingTrace.ModelInvocationOutput.TraceId
+	case t.GuardrailTrace != nil:
+		return t.GuardrailTrace.TraceId
+	case t.FailureTrace != nil:
+		return t.FailureTrace.TraceId
+	default:
+		return ""
+	}
+}
+
+// ModelInvocationInput returns the prompt of a pre-processing, orchestration or post-processing trace, if it has one.
+func (p *TracePart) ModelInvocationInput() *ModelInvocationInput {
+	switch {
+	case p.Trace.PreProcessingTrace != nil:
+		return p.Trace.PreProcessingTrace.ModelInvocationInput
+	case p.Trace.OrchestrationTrace != nil:
+		return p.Trace.OrchestrationTrace.ModelInvocationInput
+	case p.Trace.PostProcessingTrace != nil:
+		return p.Trace.PostProcessingTrace.ModelInvocationInput
+	default:
+		return nil
+	}
+}
+
+// Usage returns the tokens a model invocation used, if the trace is a model invocation's output.
+func (p *TracePart) Usage() *Usage {
+	var metadata *Metadata
+	switch {
+	case p.Trace.PreProcessingTrace != nil && p.Trace.PreProcessingTrace.ModelInvocationOutput != nil:
+		metadata = p.Trace.PreProcessingTrace.ModelInvocationOutput.Metadata
+	case p.Trace.OrchestrationTrace != nil && p.Trace.OrchestrationTrace.ModelInvocationOutput != nil:
+		metadata = p.Trace.OrchestrationTrace.ModelInvocationOutput.Metadata
+	case p.Trace.PostProcessingTrace != nil && p.Trace.PostProcessingTrace.ModelInvocationOutput != nil:
+		metadata = p.Trace.PostProcessingTrace.ModelInvocationOutput.Metadata
+	}
+
+	if metadata == nil {
+		return nil
+	}
+	return metadata.Usage
+}
+
+// PromptMessages splits the prompt into its messages when it's in the `system`/`messages` form; otherwise the whole
+// prompt is one message. Anthropic models' prompts have string contents, while Nova's (and Anthropic's, sometimes)
+// are arrays of content blocks; text blocks are joined and other blocks (e.g. tool use) are kept as JSON.
+func (i *ModelInvocationInput) PromptMessages() []*PromptMessage {
+	var prompt struct {
+		System   json.RawMessage `json:"system"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(i.Text), &prompt); err != nil || (len(prompt.System) == 0 && len(prompt.Messages) == 0) {
+		return []*PromptMessage{{Role: "prompt", Content: i.Text}}
+	}
+
+	messages := []*PromptMessage{}
+	if system := contentText(prompt.System); system != "" {
+		messages = append(messages, &PromptMessage{Role: "system", Content: system})
+	}
+	for _, message := range prompt.Messages {
+		messages = append(messages, &PromptMessage{Role: message.Role, Content: contentText(message.Content)})
+	}
+
+	return messages
+}
+
+// contentText flattens a prompt's content, which is either a string or an array of content blocks.
+func contentText(content json.RawMessage) string {
+	if len(content) == 0 || string(content) == "null" {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return string(content)
+	}
+
+	parts := []string{}
+	for _, block := range blocks {
+		var textBlock struct {
+			Text *string `json:"text"`
+		}
+		if err := json.Unmarshal(block, &textBlock); err == nil && textBlock.Text != nil {
+			parts = append(parts, *textBlock.Text)
+		} else {
+			parts = append(parts, string(block))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,170 @@
+package agent_trace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The fixtures in testdata are traces as Bedrock records them, one per kind of trace event.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture      string
+		wantKind     Kind
+		wantTraceId  string
+		wantModel    string // of the model invocation input, if the trace has one
+		wantUsage    *Usage
+		wantMessages []*PromptMessage
+	}{
+		{
+			fixture:     "pre_processing_input.json",
+			wantKind:    KindPreProcessing,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-pre-0",
+			wantMessages: []*PromptMessage{
+				{Role: "system", Content: "You are a classifying agent that filters user inputs into categories."},
+				{Role: "user", Content: "Input: Can someone grab ice for Saturday?"},
+				{Role: "assistant", Content: "Let me take a deep breath and categorize the above input, based on the conversation history into a <category></category> and add the reasoning within <thinking></thinking>"},
+			},
+		},
+		{
+			fixture:     "pre_processing_output.json",
+			wantKind:    KindPreProcessing,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-pre-0",
+			wantUsage:   &Usage{InputTokens: 812, OutputTokens: 41},
+		},
+		{
+			fixture:     "orchestration_input.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-0",
+			wantModel:   "anthropic.claude-3-5-haiku-20241022-v1:0",
+			wantMessages: []*PromptMessage{
+				{Role: "system", Content: "You track to-dos for a group text conversation."},
+				{Role: "user", Content: "Can someone grab ice for Saturday?"},
+				{Role: "assistant", Content: "I'll add a task for that.\n" + `{"type":"tool_use","id":"toolu_01","name":"task_tracking__task_tracking_create","input":{"name":"Grab ice"}}`},
+			},
+		},
+		{
+			fixture:     "orchestration_input_nova.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "7d2e9b31-5c4a-4e8f-b1d0-3a6c9e2f8b14-0",
+			wantModel:   "amazon.nova-pro-v1:0",
+			wantMessages: []*PromptMessage{
+				{Role: "system", Content: "You track to-dos for a group text conversation.\nToday is Friday, August 22, 2025."},
+				{Role: "user", Content: "Can someone grab ice for Saturday?"},
+				{Role: "assistant", Content: "<thinking>I should create a task.</thinking>\n" + `{"toolUse":{"toolUseId":"tooluse_1","name":"task_tracking__task_tracking_create","input":{"name":"Grab ice"}}}`},
+				{Role: "user", Content: `{"toolResult":{"toolUseId":"tooluse_1","content":[{"text":"{\"info\":\"Task created\"}"}]}}`},
+			},
+		},
+		{
+			fixture:     "orchestration_output.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-0",
+			wantUsage:   &Usage{InputTokens: 2104, OutputTokens: 87},
+		},
+		{
+			fixture:     "rationale.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-0",
+		},
+		{
+			fixture:     "invocation_input.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-0",
+		},
+		{
+			fixture:     "observation.json",
+			wantKind:    KindOrchestration,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-1",
+		},
+		{
+			fixture:     "post_processing_output.json",
+			wantKind:    KindPostProcessing,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-post-0",
+			wantUsage:   &Usage{InputTokens: 640, OutputTokens: 22},
+		},
+		{
+			fixture:     "guardrail.json",
+			wantKind:    KindGuardrail,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-guardrail-pre-0",
+		},
+		{
+			fixture:     "failure.json",
+			wantKind:    KindFailure,
+			wantTraceId: "0b6c4f52-1f0e-4f5b-9c3a-7a1d2e3f4a5b-0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			part, err := Parse(readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if kind := part.Kind(); kind != tt.wantKind {
+				t.Errorf("Kind() = %q, want %q", kind, tt.wantKind)
+			}
+			if traceId := part.TraceId(); traceId != tt.wantTraceId {
+				t.Errorf("TraceId() = %q, want %q", traceId, tt.wantTraceId)
+			}
+			if usage := part.Usage(); !reflect.DeepEqual(usage, tt.wantUsage) {
+				t.Errorf("Usage() = %+v, want %+v", usage, tt.wantUsage)
+			}
+
+			input := part.ModelInvocationInput()
+			if (input != nil) != (tt.wantMessages != nil) {
+				t.Fatalf("ModelInvocationInput() = %+v, want one: %v", input, tt.wantMessages != nil)
+			}
+			if input == nil {
+				return
+			}
+			if input.FoundationModel != tt.wantModel {
+				t.Errorf("foundation model = %q, want %q", input.FoundationModel, tt.wantModel)
+			}
+			messages := input.PromptMessages()
+			if len(messages) != len(tt.wantMessages) {
+				t.Fatalf("PromptMessages() = %d messages, want %d: %+v", len(messages), len(tt.wantMessages), messages)
+			}
+			for i, message := range messages {
+				if *message != *tt.wantMessages[i] {
+					t.Errorf("PromptMessages()[%d] = %+v, want %+v", i, *message, *tt.wantMessages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	if part, err := Parse(readFixture(t, "unknown.json")); err == nil {
+		t.Errorf("Parse() = %+v, want an error", part)
+	}
+	if part, err := Parse([]byte("not json")); err == nil {
+		t.Errorf("Parse() = %+v, want an error", part)
+	}
+}
+
+func TestPromptMessagesNotJson(t *testing.T) {
+	tests := []string{
+		"\n\nHuman: Can someone grab ice for Saturday?\n\nAssistant:",
+		`{"prompt": "Can someone grab ice for Saturday?"}`,
+	}
+
+	for _, text := range tests {
+		input := &ModelInvocationInput{Text: text}
+		messages := input.PromptMessages()
+		if len(messages) != 1 || *messages[0] != (PromptMessage{Role: "prompt", Content: text}) {
+			t.Errorf("PromptMessages(%q) = %+v, want the whole prompt", text, messages)
+		}
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
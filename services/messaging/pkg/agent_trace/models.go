@@ -0,0 +1,248 @@
+package agent_trace
+
+import "time"
+
+// The types follow Bedrock's trace JSON, so traces from the SDK, logs and the docs all end up in the same shape.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/trace-events.html
+
+// TracePart is one trace event from an agent invocation's stream.
+type TracePart struct {
+	AgentId      string     `json:"agentId,omitempty"`
+	AgentAliasId string     `json:"agentAliasId,omitempty"`
+	AgentVersion string     `json:"agentVersion,omitempty"`
+	SessionId    string     `json:"sessionId,omitempty"`
+	EventTime    *time.Time `json:"eventTime,omitempty"`
+	Trace        Trace      `json:"trace"`
+}
+
+// Trace has exactly one of its fields set.
+type Trace struct {
+	PreProcessingTrace  *PreProcessingTrace  `json:"preProcessingTrace,omitempty"`
+	OrchestrationTrace  *OrchestrationTrace  `json:"orchestrationTrace,omitempty"`
+	PostProcessingTrace *PostProcessingTrace `json:"postProcessingTrace,omitempty"`
+	GuardrailTrace      *GuardrailTrace      `json:"guardrailTrace,omitempty"`
+	FailureTrace        *FailureTrace        `json:"failureTrace,omitempty"`
+}
+
+type PreProcessingTrace struct {
+	ModelInvocationInput  *ModelInvocationInput               `json:"modelInvocationInput,omitempty"`
+	ModelInvocationOutput *PreProcessingModelInvocationOutput `json:"modelInvocationOutput,omitempty"`
+}
+
+// OrchestrationTrace has exactly one of its fields set.
+type OrchestrationTrace struct {
+	ModelInvocationInput  *ModelInvocationInput  `json:"modelInvocationInput,omitempty"`
+	ModelInvocationOutput *ModelInvocationOutput `json:"modelInvocationOutput,omitempty"`
+	Rationale             *Rationale             `json:"rationale,omitempty"`
+	InvocationInput       *InvocationInput       `json:"invocationInput,omitempty"`
+	Observation           *Observation           `json:"observation,omitempty"`
+}
+
+type PostProcessingTrace struct {
+	ModelInvocationInput  *ModelInvocationInput                `json:"modelInvocationInput,omitempty"`
+	ModelInvocationOutput *PostProcessingModelInvocationOutput `json:"modelInvocationOutput,omitempty"`
+}
+
+// ModelInvocationInput is the prompt sent to the model. Text is usually JSON with `system` and `messages`; see
+// PromptMessages.
+type ModelInvocationInput struct {
+	TraceId                string                  `json:"traceId"`
+	Type                   string                  `json:"type"`
+	FoundationModel        string                  `json:"foundationModel,omitempty"`
+	Text                   string                  `json:"text"`
+	InferenceConfiguration *InferenceConfiguration `json:"inferenceConfiguration,omitempty"`
+	OverrideLambda         string                  `json:"overrideLambda,omitempty"`
+	PromptCreationMode     string                  `json:"promptCreationMode,omitempty"`
+	ParserMode             string                  `json:"parserMode,omitempty"`
+}
+
+type InferenceConfiguration struct {
+	MaximumLength int      `json:"maximumLength"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+	Temperature   float64  `json:"temperature"`
+	TopK          int      `json:"topK"`
+	TopP          float64  `json:"topP"`
+}
+
+type ModelInvocationOutput struct {
+	TraceId     string       `json:"traceId"`
+	RawResponse *RawResponse `json:"rawResponse,omitempty"`
+	Metadata    *Metadata    `json:"metadata,omitempty"`
+}
+
+type PreProcessingModelInvocationOutput struct {
+	TraceId        string                       `json:"traceId"`
+	ParsedResponse *PreProcessingParsedResponse `json:"parsedResponse,omitempty"`
+	RawResponse    *RawResponse                 `json:"rawResponse,omitempty"`
+	Metadata       *Metadata                    `json:"metadata,omitempty"`
+}
+
+// PreProcessingParsedResponse is the agent's verdict on whether the input is something it should act on.
+type PreProcessingParsedResponse struct {
+	IsValid   bool   `json:"isValid"`
+	Rationale string `json:"rationale"`
+}
+
+type PostProcessingModelInvocationOutput struct {
+	TraceId        string                        `json:"traceId"`
+	ParsedResponse *PostProcessingParsedResponse `json:"parsedResponse,omitempty"`
+	RawResponse    *RawResponse                  `json:"rawResponse,omitempty"`
+	Metadata       *Metadata                     `json:"metadata,omitempty"`
+}
+
+type PostProcessingParsedResponse struct {
+	Text string `json:"text"`
+}
+
+type RawResponse struct {
+	Content string `json:"content"`
+}
+
+type Metadata struct {
+	Usage       *Usage `json:"usage,omitempty"`
+	TotalTimeMs int64  `json:"totalTimeMs,omitempty"`
+}
+
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+type Rationale struct {
+	TraceId string `json:"traceId"`
+	Text    string `json:"text"`
+}
+
+type InvocationInput struct {
+	TraceId                    string                      `json:"traceId"`
+	InvocationType             string                      `json:"invocationType"`
+	ActionGroupInvocationInput *ActionGroupInvocationInput `json:"actionGroupInvocationInput,omitempty"`
+	KnowledgeBaseLookupInput   *KnowledgeBaseLookupInput   `json:"knowledgeBaseLookupInput,omitempty"`
+}
+
+type ActionGroupInvocationInput struct {
+	ActionGroupName string       `json:"actionGroupName"`
+	Function        string       `json:"function,omitempty"`
+	ApiPath         string       `json:"apiPath,omitempty"`
+	Verb            string       `json:"verb,omitempty"`
+	ExecutionType   string       `json:"executionType,omitempty"`
+	InvocationId    string       `json:"invocationId,omitempty"`
+	Parameters      []*Parameter `json:"parameters,omitempty"`
+}
+
+type Parameter struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type KnowledgeBaseLookupInput struct {
+	KnowledgeBaseId string `json:"knowledgeBaseId"`
+	Text            string `json:"text"`
+}
+
+// Observation is what the agent saw after acting; which field is set depends on Type.
+type Observation struct {
+	TraceId                     string                       `json:"traceId"`
+	Type                        string                       `json:"type"`
+	ActionGroupInvocationOutput *ActionGroupInvocationOutput `json:"actionGroupInvocationOutput,omitempty"`
+	KnowledgeBaseLookupOutput   *KnowledgeBaseLookupOutput   `json:"knowledgeBaseLookupOutput,omitempty"`
+	FinalResponse               *FinalResponse               `json:"finalResponse,omitempty"`
+	RepromptResponse            *RepromptResponse            `json:"repromptResponse,omitempty"`
+}
+
+type ActionGroupInvocationOutput struct {
+	Text string `json:"text"`
+}
+
+type KnowledgeBaseLookupOutput struct {
+	RetrievedReferences []*RetrievedReference `json:"retrievedReferences"`
+}
+
+type RetrievedReference struct {
+	Content  *RetrievalResultContent  `json:"content,omitempty"`
+	Location *RetrievalResultLocation `json:"location,omitempty"`
+}
+
+type RetrievalResultContent struct {
+	Text string `json:"text"`
+}
+
+// RetrievalResultLocation only models the locations our knowledge bases could plausibly use.
+type RetrievalResultLocation struct {
+	Type        string       `json:"type"`
+	S3Location  *S3Location  `json:"s3Location,omitempty"`
+	WebLocation *WebLocation `json:"webLocation,omitempty"`
+}
+
+type S3Location struct {
+	Uri string `json:"uri"`
+}
+
+type WebLocation struct {
+	Url string `json:"url"`
+}
+
+type FinalResponse struct {
+	Text string `json:"text"`
+}
+
+type RepromptResponse struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+}
+
+type GuardrailTrace struct {
+	TraceId           string                 `json:"traceId"`
+	Action            string                 `json:"action"`
+	InputAssessments  []*GuardrailAssessment `json:"inputAssessments,omitempty"`
+	OutputAssessments []*GuardrailAssessment `json:"outputAssessments,omitempty"`
+}
+
+// GuardrailAssessment lists what each of the guardrail's policies matched.
+type GuardrailAssessment struct {
+	TopicPolicy                *GuardrailTopicPolicy                `json:"topicPolicy,omitempty"`
+	ContentPolicy              *GuardrailContentPolicy              `json:"contentPolicy,omitempty"`
+	WordPolicy                 *GuardrailWordPolicy                 `json:"wordPolicy,omitempty"`
+	SensitiveInformationPolicy *GuardrailSensitiveInformationPolicy `json:"sensitiveInformationPolicy,omitempty"`
+}
+
+type GuardrailTopicPolicy struct {
+	Topics []*GuardrailMatch `json:"topics"`
+}
+
+type GuardrailContentPolicy struct {
+	Filters []*GuardrailMatch `json:"filters"`
+}
+
+type GuardrailWordPolicy struct {
+	CustomWords      []*GuardrailMatch `json:"customWords,omitempty"`
+	ManagedWordLists []*GuardrailMatch `json:"managedWordLists,omitempty"`
+}
+
+type GuardrailSensitiveInformationPolicy struct {
+	PiiEntities []*GuardrailMatch `json:"piiEntities,omitempty"`
+	Regexes     []*GuardrailMatch `json:"regexes,omitempty"`
+}
+
+// GuardrailMatch covers the policies' match types; each one only uses some of the fields.
+type GuardrailMatch struct {
+	Action     string `json:"action"`
+	Type       string `json:"type,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Match      string `json:"match,omitempty"`
+	Regex      string `json:"regex,omitempty"`
+	Confidence string `json:"confidence,omitempty"`
+}
+
+type FailureTrace struct {
+	TraceId       string `json:"traceId"`
+	FailureCode   int    `json:"failureCode,omitempty"`
+	FailureReason string `json:"failureReason"`
+}
+
+// PromptMessage is one message of a model invocation's prompt.
+type PromptMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
@@ -0,0 +1,312 @@
+package agent_trace
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsTypes "github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+)
+
+// FromTracePart converts a trace event from the SDK's invoke agent stream. Trace types we don't model (e.g. routing
+// classifier traces) come back with an empty Trace, whose Kind is KindUnknown.
+func FromTracePart(part awsTypes.TracePart) *TracePart {
+	tracePart := &TracePart{
+		AgentId:      aws.ToString(part.AgentId),
+		AgentAliasId: aws.ToString(part.AgentAliasId),
+		AgentVersion: aws.ToString(part.AgentVersion),
+		SessionId:    aws.ToString(part.SessionId),
+		EventTime:    part.EventTime,
+	}
+
+	switch t := part.Trace.(type) {
+	case *awsTypes.TraceMemberPreProcessingTrace:
+		tracePart.Trace.PreProcessingTrace = fromPreProcessingTrace(t.Value)
+	case *awsTypes.TraceMemberOrchestrationTrace:
+		tracePart.Trace.OrchestrationTrace = fromOrchestrationTrace(t.Value)
+	case *awsTypes.TraceMemberPostProcessingTrace:
+		tracePart.Trace.PostProcessingTrace = fromPostProcessingTrace(t.Value)
+	case *awsTypes.TraceMemberGuardrailTrace:
+		tracePart.Trace.GuardrailTrace = fromGuardrailTrace(t.Value)
+	case *awsTypes.TraceMemberFailureTrace:
+		tracePart.Trace.FailureTrace = &FailureTrace{
+			TraceId:       aws.ToString(t.Value.TraceId),
+			FailureCode:   int(aws.ToInt32(t.Value.FailureCode)),
+			FailureReason: aws.ToString(t.Value.FailureReason),
+		}
+	}
+
+	return tracePart
+}
+
+func fromPreProcessingTrace(trace awsTypes.PreProcessingTrace) *PreProcessingTrace {
+	switch t := trace.(type) {
+	case *awsTypes.PreProcessingTraceMemberModelInvocationInput:
+		return &PreProcessingTrace{ModelInvocationInput: fromModelInvocationInput(t.Value)}
+	case *awsTypes.PreProcessingTraceMemberModelInvocationOutput:
+		output := &PreProcessingModelInvocationOutput{
+			TraceId:     aws.ToString(t.Value.TraceId),
+			RawResponse: fromRawResponse(t.Value.RawResponse),
+			Metadata:    fromMetadata(t.Value.Metadata),
+		}
+		if t.Value.ParsedResponse != nil {
+			output.ParsedResponse = &PreProcessingParsedResponse{
+				IsValid:   aws.ToBool(t.Value.ParsedResponse.IsValid),
+				Rationale: aws.ToString(t.Value.ParsedResponse.Rationale),
+			}
+		}
+		return &PreProcessingTrace{ModelInvocationOutput: output}
+	default:
+		return &PreProcessingTrace{}
+	}
+}
+
+func fromOrchestrationTrace(trace awsTypes.OrchestrationTrace) *OrchestrationTrace {
+	switch t := trace.(type) {
+	case *awsTypes.OrchestrationTraceMemberModelInvocationInput:
+		return &OrchestrationTrace{ModelInvocationInput: fromModelInvocationInput(t.Value)}
+	case *awsTypes.OrchestrationTraceMemberModelInvocationOutput:
+		return &OrchestrationTrace{ModelInvocationOutput: &ModelInvocationOutput{
+			TraceId:     aws.ToString(t.Value.TraceId),
+			RawResponse: fromRawResponse(t.Value.RawResponse),
+			Metadata:    fromMetadata(t.Value.Metadata),
+		}}
+	case *awsTypes.OrchestrationTraceMemberRationale:
+		return &OrchestrationTrace{Rationale: &Rationale{
+			TraceId: aws.ToString(t.Value.TraceId),
+			Text:    aws.ToString(t.Value.Text),
+		}}
+	case *awsTypes.OrchestrationTraceMemberInvocationInput:
+		return &OrchestrationTrace{InvocationInput: fromInvocationInput(t.Value)}
+	case *awsTypes.OrchestrationTraceMemberObservation:
+		return &OrchestrationTrace{Observation: fromObservation(t.Value)}
+	default:
+		return &OrchestrationTrace{}
+	}
+}
+
+func fromPostProcessingTrace(trace awsTypes.PostProcessingTrace) *PostProcessingTrace {
+	switch t := trace.(type) {
+	case *awsTypes.PostProcessingTraceMemberModelInvocationInput:
+		return &PostProcessingTrace{ModelInvocationInput: fromModelInvocationInput(t.Value)}
+	case *awsTypes.PostProcessingTraceMemberModelInvocationOutput:
+		output := &PostProcessingModelInvocationOutput{
+			TraceId:     aws.ToString(t.Value.TraceId),
+			RawResponse: fromRawResponse(t.Value.RawResponse),
+			Metadata:    fromMetadata(t.Value.Metadata),
+		}
+		if t.Value.ParsedResponse != nil {
+			output.ParsedResponse = &PostProcessingParsedResponse{Text: aws.ToString(t.Value.ParsedResponse.Text)}
+		}
+		return &PostProcessingTrace{ModelInvocationOutput: output}
+	default:
+		return &PostProcessingTrace{}
+	}
+}
+
+func fromModelInvocationInput(input awsTypes.ModelInvocationInput) *ModelInvocationInput {
+	modelInvocationInput := &ModelInvocationInput{
+		TraceId:            aws.ToString(input.TraceId),
+		Type:               string(input.Type),
+		FoundationModel:    aws.ToString(input.FoundationModel),
+		Text:               aws.ToString(input.Text),
+		OverrideLambda:     aws.ToString(input.OverrideLambda),
+		PromptCreationMode: string(input.PromptCreationMode),
+		ParserMode:         string(input.ParserMode),
+	}
+
+	if c := input.InferenceConfiguration; c != nil {
+		modelInvocationInput.InferenceConfiguration = &InferenceConfiguration{
+			MaximumLength: int(aws.ToInt32(c.MaximumLength)),
+			StopSequences: c.StopSequences,
+			Temperature:   float64(aws.ToFloat32(c.Temperature)),
+			TopK:          int(aws.ToInt32(c.TopK)),
+			TopP:          float64(aws.ToFloat32(c.TopP)),
+		}
+	}
+
+	return modelInvocationInput
+}
+
+func fromInvocationInput(input awsTypes.InvocationInput) *InvocationInput {
+	invocationInput := &InvocationInput{
+		TraceId:        aws.ToString(input.TraceId),
+		InvocationType: string(input.InvocationType),
+	}
+
+	if a := input.ActionGroupInvocationInput; a != nil {
+		parameters := []*Parameter{}
+		for _, parameter := range a.Parameters {
+			parameters = append(parameters, &Parameter{
+				Name:  aws.ToString(parameter.Name),
+				Type:  aws.ToString(parameter.Type),
+				Value: aws.ToString(parameter.Value),
+			})
+		}
+		invocationInput.ActionGroupInvocationInput = &ActionGroupInvocationInput{
+			ActionGroupName: aws.ToString(a.ActionGroupName),
+			Function:        aws.ToString(a.Function),
+			ApiPath:         aws.ToString(a.ApiPath),
+			Verb:            aws.ToString(a.Verb),
+			ExecutionType:   string(a.ExecutionType),
+			InvocationId:    aws.ToString(a.InvocationId),
+			Parameters:      parameters,
+		}
+	}
+
+	if k := input.KnowledgeBaseLookupInput; k != nil {
+		invocationInput.KnowledgeBaseLookupInput = &KnowledgeBaseLookupInput{
+			KnowledgeBaseId: aws.ToString(k.KnowledgeBaseId),
+			Text:            aws.ToString(k.Text),
+		}
+	}
+
+	return invocationInput
+}
+
+func fromObservation(observation awsTypes.Observation) *Observation {
+	o := &Observation{
+		TraceId: aws.ToString(observation.TraceId),
+		Type:    string(observation.Type),
+	}
+
+	if observation.ActionGroupInvocationOutput != nil {
+		o.ActionGroupInvocationOutput = &ActionGroupInvocationOutput{Text: aws.ToString(observation.ActionGroupInvocationOutput.Text)}
+	}
+	if observation.FinalResponse != nil {
+		o.FinalResponse = &FinalResponse{Text: aws.ToString(observation.FinalResponse.Text)}
+	}
+	if observation.RepromptResponse != nil {
+		o.RepromptResponse = &RepromptResponse{
+			Text:   aws.ToString(observation.RepromptResponse.Text),
+			Source: string(observation.RepromptResponse.Source),
+		}
+	}
+	if observation.KnowledgeBaseLookupOutput != nil {
+		references := []*RetrievedReference{}
+		for _, r := range observation.KnowledgeBaseLookupOutput.RetrievedReferences {
+			references = append(references, fromRetrievedReference(r))
+		}
+		o.KnowledgeBaseLookupOutput = &KnowledgeBaseLookupOutput{RetrievedReferences: references}
+	}
+
+	return o
+}
+
+func fromRetrievedReference(reference awsTypes.RetrievedReference) *RetrievedReference {
+	r := &RetrievedReference{}
+
+	if reference.Content != nil {
+		r.Content = &RetrievalResultContent{Text: aws.ToString(reference.Content.Text)}
+	}
+
+	if l := reference.Location; l != nil {
+		r.Location = &RetrievalResultLocation{Type: string(l.Type)}
+		if l.S3Location != nil {
+			r.Location.S3Location = &S3Location{Uri: aws.ToString(l.S3Location.Uri)}
+		}
+		if l.WebLocation != nil {
+			r.Location.WebLocation = &WebLocation{Url: aws.ToString(l.WebLocation.Url)}
+		}
+	}
+
+	return r
+}
+
+func fromGuardrailTrace(trace awsTypes.GuardrailTrace) *GuardrailTrace {
+	return &GuardrailTrace{
+		TraceId:           aws.ToString(trace.TraceId),
+		Action:            string(trace.Action),
+		InputAssessments:  fromGuardrailAssessments(trace.InputAssessments),
+		OutputAssessments: fromGuardrailAssessments(trace.OutputAssessments),
+	}
+}
+
+func fromGuardrailAssessments(assessments []awsTypes.GuardrailAssessment) []*GuardrailAssessment {
+	result := []*GuardrailAssessment{}
+	for _, assessment := range assessments {
+		a := &GuardrailAssessment{}
+
+		if p := assessment.TopicPolicy; p != nil {
+			a.TopicPolicy = &GuardrailTopicPolicy{Topics: []*GuardrailMatch{}}
+			for _, topic := range p.Topics {
+				a.TopicPolicy.Topics = append(a.TopicPolicy.Topics, &GuardrailMatch{
+					Action: string(topic.Action),
+					Type:   string(topic.Type),
+					Name:   aws.ToString(topic.Name),
+				})
+			}
+		}
+
+		if p := assessment.ContentPolicy; p != nil {
+			a.ContentPolicy = &GuardrailContentPolicy{Filters: []*GuardrailMatch{}}
+			for _, filter := range p.Filters {
+				a.ContentPolicy.Filters = append(a.ContentPolicy.Filters, &GuardrailMatch{
+					Action:     string(filter.Action),
+					Type:       string(filter.Type),
+					Confidence: string(filter.Confidence),
+				})
+			}
+		}
+
+		if p := assessment.WordPolicy; p != nil {
+			a.WordPolicy = &GuardrailWordPolicy{}
+			for _, word := range p.CustomWords {
+				a.WordPolicy.CustomWords = append(a.WordPolicy.CustomWords, &GuardrailMatch{
+					Action: string(word.Action),
+					Match:  aws.ToString(word.Match),
+				})
+			}
+			for _, word := range p.ManagedWordLists {
+				a.WordPolicy.ManagedWordLists = append(a.WordPolicy.ManagedWordLists, &GuardrailMatch{
+					Action: string(word.Action),
+					Type:   string(word.Type),
+					Match:  aws.ToString(word.Match),
+				})
+			}
+		}
+
+		if p := assessment.SensitiveInformationPolicy; p != nil {
+			a.SensitiveInformationPolicy = &GuardrailSensitiveInformationPolicy{}
+			for _, entity := range p.PiiEntities {
+				a.SensitiveInformationPolicy.PiiEntities = append(a.SensitiveInformationPolicy.PiiEntities, &GuardrailMatch{
+					Action: string(entity.Action),
+					Type:   string(entity.Type),
+					Match:  aws.ToString(entity.Match),
+				})
+			}
+			for _, regex := range p.Regexes {
+				a.SensitiveInformationPolicy.Regexes = append(a.SensitiveInformationPolicy.Regexes, &GuardrailMatch{
+					Action: string(regex.Action),
+					Name:   aws.ToString(regex.Name),
+					Match:  aws.ToString(regex.Match),
+					Regex:  aws.ToString(regex.Regex),
+				})
+			}
+		}
+
+		result = append(result, a)
+	}
+
+	return result
+}
+
+func fromRawResponse(rawResponse *awsTypes.RawResponse) *RawResponse {
+	if rawResponse == nil {
+		return nil
+	}
+	return &RawResponse{Content: aws.ToString(rawResponse.Content)}
+}
+
+func fromMetadata(metadata *awsTypes.Metadata) *Metadata {
+	if metadata == nil {
+		return nil
+	}
+
+	m := &Metadata{TotalTimeMs: aws.ToInt64(metadata.TotalTimeMs)}
+	if metadata.Usage != nil {
+		m.Usage = &Usage{
+			InputTokens:  int(aws.ToInt32(metadata.Usage.InputTokens)),
+			OutputTokens: int(aws.ToInt32(metadata.Usage.OutputTokens)),
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,55 @@
+package attachment_service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/blob_store"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/media_describer"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// AttachmentService stores the files that come in with messages and generates a description for each of them.
+type AttachmentService struct {
+	blobStore blob_store.BlobStore
+	describer media_describer.MediaDescriber
+}
+
+func NewAttachmentService(blobStore blob_store.BlobStore, describer media_describer.MediaDescriber) *AttachmentService {
+	return &AttachmentService{blobStore: blobStore, describer: describer}
+}
+
+// Store saves the file under the conversation and describes it. Failing to describe the file isn't fatal; the
+// attachment is returned without a description.
+func (s *AttachmentService) Store(ctx context.Context, conversationId, contentType string, data []byte) (*message_repository.Attachment, error) {
+	logger := zerolog.Ctx(ctx)
+
+	attachment := &message_repository.Attachment{
+		Id:          uuid.NewString(),
+		ContentType: contentType,
+	}
+	attachment.Key = fmt.Sprintf("%s/%s", conversationId, attachment.Id)
+
+	url, err := s.blobStore.Put(ctx, attachment.Key, contentType, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	attachment.Url = url
+
+	description, err := s.describer.Describe(ctx, contentType, data)
+	if err != nil {
+		logger.Error().Err(err).Str("attachment_id", attachment.Id).Msg("failed to describe attachment")
+	} else {
+		attachment.Description = description
+	}
+
+	return attachment, nil
+}
+
+// DownloadUrl returns a URL the attachment can be fetched from without credentials. It may expire.
+func (s *AttachmentService) DownloadUrl(ctx context.Context, attachment *message_repository.Attachment) (string, error) {
+	return s.blobStore.DownloadUrl(ctx, attachment.Key)
+}
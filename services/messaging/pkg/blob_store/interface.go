@@ -0,0 +1,15 @@
+package blob_store
+
+import (
+	"context"
+	"io"
+)
+
+type BlobStore interface {
+	// Put stores the blob and returns a URL that identifies where it's stored (e.g. `s3://bucket/key`).
+	Put(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+	// Get returns the blob and its content type. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// DownloadUrl returns a URL the blob can be fetched from without credentials. It may expire.
+	DownloadUrl(ctx context.Context, key string) (string, error)
+}
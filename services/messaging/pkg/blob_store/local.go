@@ -0,0 +1,69 @@
+package blob_store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local keeps blobs on the local filesystem; it's meant for tests and local runs. The content type is kept in a
+// sidecar file next to the blob.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+func (l *Local) Put(ctx context.Context, key, contentType string, body io.Reader) (string, error) {
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, body); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.WriteFile(path+".content-type", []byte(contentType), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write content type: %w", err)
+	}
+
+	return "file://" + path, nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	path := l.path(key)
+
+	contentType, err := os.ReadFile(path + ".content-type")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read content type: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, string(contentType), nil
+}
+
+func (l *Local) DownloadUrl(ctx context.Context, key string) (string, error) {
+	return "file://" + l.path(key), nil
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
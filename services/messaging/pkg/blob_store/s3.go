@@ -0,0 +1,72 @@
+package blob_store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const downloadUrlExpiration = time.Hour
+
+type S3 struct {
+	bucket    string
+	client    *s3.Client
+	presigner *s3.PresignClient
+}
+
+func NewS3(ctx context.Context, bucket string) (BlobStore, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	client := s3.NewFromConfig(cfg)
+	return &S3{
+		bucket:    bucket,
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, body io.Reader) (string, error) {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to put object in S3: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object from S3: %w", err)
+	}
+
+	return result.Body, aws.ToString(result.ContentType), nil
+}
+
+func (s *S3) DownloadUrl(ctx context.Context, key string) (string, error) {
+	request, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(downloadUrlExpiration))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object: %w", err)
+	}
+
+	return request.URL, nil
+}
@@ -0,0 +1,209 @@
+package coalesce_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_invoker"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/job_queue"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/pending_run_repository"
+	"github.com/rs/zerolog"
+)
+
+// busyRetryDelay is how long a run waits when another run is working on the conversation.
+const busyRetryDelay = 15 * time.Second
+
+// DefaultWindow is how long a conversation has to be quiet before the agent runs for its new messages.
+const DefaultWindow = 5 * time.Second
+
+// AgentInvoker runs the agent for a conversation; it's an *agent_invoker.Invoker outside of tests.
+type AgentInvoker interface {
+	Invoke(ctx context.Context, conversationId, input string) (*agent_service.InvokeResult, *message_repository.Message, error)
+	SendAnswer(ctx context.Context, conversationId, messageId, body string) (*message_repository.Message, error)
+}
+
+// CoalesceService collects a conversation's messages as they come in and runs the agent once for all of them, after
+// the conversation has been quiet for the window. That way a burst of replies ("ok", "sounds good", ...) is one run
+// instead of several racing each other.
+type CoalesceService struct {
+	queue  job_queue.Queue
+	repo   pending_run_repository.PendingRunRepository
+	window time.Duration
+}
+
+// A window of 0 still goes through the queue, but runs the agent as soon as the job is handled.
+func NewCoalesceService(queue job_queue.Queue, repo pending_run_repository.PendingRunRepository, window time.Duration) *CoalesceService {
+	return &CoalesceService{
+		queue:  queue,
+		repo:   repo,
+		window: window,
+	}
+}
+
+// ParseWindow parses a duration like "5s"; empty keeps the default.
+func ParseWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return DefaultWindow, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid coalesce window: %s", window)
+	}
+
+	return d, nil
+}
+
+// MessageReceived adds the (already saved) message to the conversation's pending run and schedules a check for when
+// the window is up.
+func (s *CoalesceService) MessageReceived(ctx context.Context, conversationId, messageId string) error {
+	pendingRun, err := s.repo.AddMessages(conversationId, []string{messageId}, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to add message to pending run: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("conversation_id", conversationId).
+		Str("message_id", messageId).
+		Int("pending_messages", len(pendingRun.MessageIds)).
+		Msg("message added to pending run")
+
+	err = s.queue.Enqueue(ctx, &job_queue.Job{
+		Type:           job_queue.JobTypeInvokeAgent,
+		ConversationId: conversationId,
+	}, s.window)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return nil
+}
+
+// JobHandler returns the handler for the jobs MessageReceived enqueues; only the worker that runs the agent needs it.
+func (s *CoalesceService) JobHandler(agentInvoker AgentInvoker) job_queue.Handler {
+	return func(ctx context.Context, job *job_queue.Job) error {
+		switch job.Type {
+		case job_queue.JobTypeInvokeAgent:
+			return s.handleJob(ctx, agentInvoker, job)
+		case job_queue.JobTypeSendAnswer:
+			return s.handleSendAnswer(ctx, agentInvoker, job)
+		default:
+			return fmt.Errorf("unknown job type: %s", job.Type)
+		}
+	}
+}
+
+// handleJob runs the agent for the conversation's pending run if the conversation has been quiet for the window. If
+// it hasn't, the job is pushed back until it will have been; every message schedules its own job, so there's always
+// one left to pick up the run.
+func (s *CoalesceService) handleJob(ctx context.Context, agentInvoker AgentInvoker, job *job_queue.Job) error {
+	logger := zerolog.Ctx(ctx)
+
+	pendingRun, err := s.repo.GetPendingRun(job.ConversationId)
+	if err != nil {
+		return fmt.Errorf("failed to get pending run: %w", err)
+	}
+	if pendingRun == nil {
+		logger.Info().Str("conversation_id", job.ConversationId).Msg("no pending run, another job got to it first")
+		return nil
+	}
+
+	quietFor := time.Since(time.UnixMilli(pendingRun.LastMessageAt))
+	if quietFor < s.window {
+		if err := s.queue.Enqueue(ctx, job, s.window-quietFor); err != nil {
+			return fmt.Errorf("failed to re-enqueue job: %w", err)
+		}
+		return nil
+	}
+
+	err = s.repo.ClaimPendingRun(job.ConversationId, pendingRun.Version)
+	if errors.Is(err, pending_run_repository.ErrConflict) {
+		// Either another job claimed it, or a message just came in and its job will pick the run up.
+		logger.Info().Str("conversation_id", job.ConversationId).Msg("pending run changed, leaving it")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim pending run: %w", err)
+	}
+
+	logger.Info().
+		Str("conversation_id", job.ConversationId).
+		Strs("message_ids", pendingRun.MessageIds).
+		Msg("running agent for pending messages")
+
+	input := agent_invoker.NewMessagesInput(job.ConversationId, pendingRun.MessageIds)
+	_, _, err = agentInvoker.Invoke(ctx, job.ConversationId, input)
+	if err == nil {
+		return nil
+	}
+
+	// Once the agent has run it may have acted on the messages, so it isn't run for them again.
+	var unsent *agent_invoker.UnsentAnswerError
+	if errors.As(err, &unsent) {
+		logger.Error().Err(err).Str("conversation_id", job.ConversationId).Msg("agent ran but its answer wasn't sent, sending it later")
+		return s.enqueueSendAnswer(ctx, job.ConversationId, unsent)
+	}
+	if errors.Is(err, agent_invoker.ErrAgentFailed) {
+		logger.Error().Err(err).Str("conversation_id", job.ConversationId).Strs("message_ids", pendingRun.MessageIds).Msg("agent run failed, not retrying it")
+		return nil
+	}
+
+	// The agent didn't run; put the messages back so the next run picks them up, along with any that come in before it.
+	if _, restoreErr := s.repo.AddMessages(job.ConversationId, pendingRun.MessageIds, pendingRun.LastMessageAt); restoreErr != nil {
+		logger.Error().Err(restoreErr).Str("conversation_id", job.ConversationId).Strs("message_ids", pendingRun.MessageIds).Msg("failed to restore pending run")
+	}
+
+	var deferred *agent_invoker.DeferredError
+	if errors.As(err, &deferred) {
+		logger.Info().Str("conversation_id", job.ConversationId).Time("retry_at", deferred.RetryAt).Msg("conversation is over its limit, trying again when it's allowed")
+		if err := s.queue.Enqueue(ctx, job, time.Until(deferred.RetryAt)); err != nil {
+			return fmt.Errorf("failed to re-enqueue job: %w", err)
+		}
+		return nil
+	}
+
+	if errors.Is(err, agent_invoker.ErrConversationBusy) {
+		logger.Info().Str("conversation_id", job.ConversationId).Msg("conversation is busy, trying again later")
+		if err := s.queue.Enqueue(ctx, job, busyRetryDelay); err != nil {
+			return fmt.Errorf("failed to re-enqueue job: %w", err)
+		}
+		return nil
+	}
+
+	return err
+}
+
+// handleSendAnswer retries sending an answer. Once the answer is saved as a message the retry is a new job with its
+// ID, so that later attempts don't save it again.
+func (s *CoalesceService) handleSendAnswer(ctx context.Context, agentInvoker AgentInvoker, job *job_queue.Job) error {
+	_, err := agentInvoker.SendAnswer(ctx, job.ConversationId, job.MessageId, job.Body)
+	if err == nil {
+		return nil
+	}
+
+	var unsent *agent_invoker.UnsentAnswerError
+	if job.MessageId == "" && errors.As(err, &unsent) && unsent.MessageId != "" {
+		zerolog.Ctx(ctx).Error().Err(err).Str("conversation_id", job.ConversationId).Msg("answer was saved but not sent, sending it later")
+		return s.enqueueSendAnswer(ctx, job.ConversationId, unsent)
+	}
+
+	return err
+}
+
+func (s *CoalesceService) enqueueSendAnswer(ctx context.Context, conversationId string, unsent *agent_invoker.UnsentAnswerError) error {
+	err := s.queue.Enqueue(ctx, &job_queue.Job{
+		Type:           job_queue.JobTypeSendAnswer,
+		ConversationId: conversationId,
+		MessageId:      unsent.MessageId,
+		Body:           unsent.Body,
+	}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue answer: %w", err)
+	}
+
+	return nil
+}
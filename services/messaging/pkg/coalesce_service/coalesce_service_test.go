@@ -0,0 +1,137 @@
+package coalesce_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_invoker"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/job_queue"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/pending_run_repository"
+)
+
+// fakeAgentInvoker records the input of each run and returns the next of `errs` for it (nil once they run out). It
+// records the answers it's asked to send too.
+type fakeAgentInvoker struct {
+	mu      sync.Mutex
+	inputs  []string
+	errs    []error
+	answers []string
+}
+
+func (f *fakeAgentInvoker) Invoke(ctx context.Context, conversationId, input string) (*agent_service.InvokeResult, *message_repository.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inputs = append(f.inputs, input)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &agent_service.InvokeResult{}, nil, nil
+}
+
+func (f *fakeAgentInvoker) SendAnswer(ctx context.Context, conversationId, messageId, body string) (*message_repository.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.answers = append(f.answers, messageId+":"+body)
+	return &message_repository.Message{Id: messageId, ConversationId: conversationId, Body: body}, nil
+}
+
+func TestMessageReceived(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	messageIds := []string{"m1", "m2", "m3"}
+
+	tests := []struct {
+		name        string
+		errs        []error
+		wantInputs  int
+		wantAnswers []string
+	}{
+		{
+			name:       "one run for the burst",
+			wantInputs: 1,
+		},
+		{
+			name:       "run that fails before the agent runs is retried with the same messages",
+			errs:       []error{errors.New("failed to acquire conversation lock: throttled")},
+			wantInputs: 2,
+		},
+		{
+			name:       "run where the agent failed isn't retried",
+			errs:       []error{fmt.Errorf("%w: throttled", agent_invoker.ErrAgentFailed)},
+			wantInputs: 1,
+		},
+		{
+			name:        "unsent answer is sent without running the agent again",
+			errs:        []error{&agent_invoker.UnsentAnswerError{MessageId: "a1", Body: "Got it.", Err: errors.New("failed to deliver message")}},
+			wantInputs:  1,
+			wantAnswers: []string{"a1:Got it."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			queue := job_queue.NewMemory()
+			service := NewCoalesceService(queue, pending_run_repository.NewMemory(), 50*time.Millisecond)
+			invoker := &fakeAgentInvoker{errs: tt.errs}
+			queue.Consume(service.JobHandler(invoker))
+
+			for _, messageId := range messageIds {
+				if err := service.MessageReceived(ctx, conversationId, messageId); err != nil {
+					t.Fatalf("MessageReceived() error = %v", err)
+				}
+			}
+			queue.Wait()
+
+			if len(invoker.inputs) != tt.wantInputs {
+				t.Fatalf("agent ran %d times, want %d: %q", len(invoker.inputs), tt.wantInputs, invoker.inputs)
+			}
+			want := agent_invoker.NewMessagesInput(conversationId, messageIds)
+			for _, input := range invoker.inputs {
+				if input != want {
+					t.Errorf("input = %q, want %q", input, want)
+				}
+			}
+			if !slices.Equal(invoker.answers, tt.wantAnswers) {
+				t.Errorf("answers = %q, want %q", invoker.answers, tt.wantAnswers)
+			}
+			if deadLetters := queue.DeadLetters(); len(deadLetters) != 0 {
+				t.Errorf("dead letters = %d, want 0", len(deadLetters))
+			}
+		})
+	}
+}
+
+func TestMessageReceivedTwice(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	ctx := context.Background()
+	queue := job_queue.NewMemory()
+	service := NewCoalesceService(queue, pending_run_repository.NewMemory(), 50*time.Millisecond)
+	invoker := &fakeAgentInvoker{}
+	queue.Consume(service.JobHandler(invoker))
+
+	// A webhook retry schedules the same message again.
+	for _, messageId := range []string{"m1", "m2", "m1"} {
+		if err := service.MessageReceived(ctx, conversationId, messageId); err != nil {
+			t.Fatalf("MessageReceived() error = %v", err)
+		}
+	}
+	queue.Wait()
+
+	want := []string{agent_invoker.NewMessagesInput(conversationId, []string{"m1", "m2"})}
+	if !slices.Equal(invoker.inputs, want) {
+		t.Errorf("inputs = %q, want %q", invoker.inputs, want)
+	}
+}
@@ -0,0 +1,79 @@
+package compliance_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/opt_out_repository"
+	"github.com/rs/zerolog"
+)
+
+const DefaultHelpMessage = "Text Agent: an AI assistant that tracks to-dos for your group. Reply STOP to stop receiving messages, START to resume."
+
+// ComplianceService handles the carrier mandated STOP, START and HELP keywords.
+type ComplianceService struct {
+	optOutRepo  opt_out_repository.OptOutRepository
+	helpMessage string
+}
+
+func NewComplianceService(optOutRepo opt_out_repository.OptOutRepository, helpMessage string) *ComplianceService {
+	return &ComplianceService{optOutRepo: optOutRepo, helpMessage: helpMessage}
+}
+
+// HandleInbound checks an inbound message for a keyword and updates the sender's opt-out state. It returns the
+// keyword (KeywordNone if there isn't one) and, for HELP, the reply to send back to the sender. Messages with a
+// keyword shouldn't be passed on to the agent.
+//
+// An ambiguous keyword (e.g. "Yes") still updates the opt-out state, since Twilio acts on it, but it's only returned if
+// it changed the state; otherwise it's an ordinary message.
+//
+// We don't reply to STOP and START; Twilio's opt-out handling sends the confirmations.
+func (s *ComplianceService) HandleInbound(ctx context.Context, from, body string) (Keyword, string, error) {
+	logger := zerolog.Ctx(ctx)
+
+	keyword, ambiguous := ParseKeyword(body)
+	switch keyword {
+	case KeywordStop, KeywordStart:
+		optedOut := keyword == KeywordStop
+		optOuts, err := s.optOutRepo.GetOptOuts([]string{from})
+		if err != nil {
+			return keyword, "", fmt.Errorf("failed to get opt out: %w", err)
+		}
+		current, ok := optOuts[from]
+		if (ok && current.OptedOut == optedOut) || (!ok && !optedOut) {
+			logger.Info().Str("from", from).Str("keyword", string(keyword)).Bool("ambiguous", ambiguous).Msg("opt out unchanged")
+			if ambiguous {
+				return KeywordNone, "", nil
+			}
+			return keyword, "", nil
+		}
+
+		if _, err := s.optOutRepo.SetOptedOut(from, optedOut, string(keyword)); err != nil {
+			return keyword, "", fmt.Errorf("failed to set opt out: %w", err)
+		}
+		logger.Info().Str("from", from).Str("keyword", string(keyword)).Msg("updated opt out")
+		return keyword, "", nil
+	case KeywordHelp:
+		return keyword, s.helpMessage, nil
+	default:
+		return KeywordNone, "", nil
+	}
+}
+
+// OptedOut returns the numbers that have opted out, sorted.
+func (s *ComplianceService) OptedOut(phoneNumbers []string) ([]string, error) {
+	optOuts, err := s.optOutRepo.GetOptOuts(phoneNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opt outs: %w", err)
+	}
+
+	optedOut := []string{}
+	for phoneNumber, optOut := range optOuts {
+		if optOut.OptedOut {
+			optedOut = append(optedOut, phoneNumber)
+		}
+	}
+	sort.Strings(optedOut)
+	return optedOut, nil
+}
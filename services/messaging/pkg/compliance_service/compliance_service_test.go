@@ -0,0 +1,90 @@
+package compliance_service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/opt_out_repository"
+)
+
+type fakeOptOutRepository struct {
+	optOuts map[string]*opt_out_repository.OptOut
+	sets    int
+}
+
+func (r *fakeOptOutRepository) GetOptOuts(phoneNumbers []string) (map[string]*opt_out_repository.OptOut, error) {
+	optOuts := map[string]*opt_out_repository.OptOut{}
+	for _, phoneNumber := range phoneNumbers {
+		if optOut, ok := r.optOuts[phoneNumber]; ok {
+			optOuts[phoneNumber] = optOut
+		}
+	}
+	return optOuts, nil
+}
+
+func (r *fakeOptOutRepository) SetOptedOut(phoneNumber string, optedOut bool, keyword string) (*opt_out_repository.OptOut, error) {
+	r.sets++
+	optOut := &opt_out_repository.OptOut{PhoneNumber: phoneNumber, OptedOut: optedOut, Keyword: keyword}
+	r.optOuts[phoneNumber] = optOut
+	return optOut, nil
+}
+
+func TestHandleInbound(t *testing.T) {
+	const from = "+15555550101"
+
+	tests := []struct {
+		name         string
+		optedOut     *bool // nil if the sender has never opted out or in
+		body         string
+		wantKeyword  Keyword
+		wantReply    string
+		wantOptedOut bool
+		wantSets     int
+	}{
+		{name: "message", body: "Can someone grab ice?", wantKeyword: KeywordNone},
+		{name: "stop", body: "STOP", wantKeyword: KeywordStop, wantOptedOut: true, wantSets: 1},
+		{name: "stop with punctuation", body: " stop! ", wantKeyword: KeywordStop, wantOptedOut: true, wantSets: 1},
+		{name: "stop when opted out", optedOut: ptr(true), body: "Stop", wantKeyword: KeywordStop, wantOptedOut: true},
+		{name: "start when opted out", optedOut: ptr(true), body: "START", wantKeyword: KeywordStart, wantSets: 1},
+		{name: "cancel", body: "cancel", wantKeyword: KeywordStop, wantOptedOut: true, wantSets: 1},
+		{name: "cancel when opted out", optedOut: ptr(true), body: "Cancel", wantKeyword: KeywordNone, wantOptedOut: true},
+		{name: "yes", body: "Yes", wantKeyword: KeywordNone},
+		{name: "yes when opted in", optedOut: ptr(false), body: "yes!", wantKeyword: KeywordNone},
+		{name: "yes when opted out", optedOut: ptr(true), body: "YES", wantKeyword: KeywordStart, wantSets: 1},
+		{name: "help", body: "help", wantKeyword: KeywordHelp, wantReply: DefaultHelpMessage},
+		{name: "info", body: "Info", wantKeyword: KeywordNone},
+		{name: "stop in a sentence", body: "stop by the store", wantKeyword: KeywordNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOptOutRepository{optOuts: map[string]*opt_out_repository.OptOut{}}
+			if tt.optedOut != nil {
+				repo.optOuts[from] = &opt_out_repository.OptOut{PhoneNumber: from, OptedOut: *tt.optedOut}
+			}
+			service := NewComplianceService(repo, DefaultHelpMessage)
+
+			keyword, reply, err := service.HandleInbound(context.Background(), from, tt.body)
+			if err != nil {
+				t.Fatalf("HandleInbound() error = %v", err)
+			}
+			if keyword != tt.wantKeyword {
+				t.Errorf("keyword = %q, want %q", keyword, tt.wantKeyword)
+			}
+			if reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+			optOut := repo.optOuts[from]
+			if optedOut := optOut != nil && optOut.OptedOut; optedOut != tt.wantOptedOut {
+				t.Errorf("opted out = %v, want %v", optedOut, tt.wantOptedOut)
+			}
+			if repo.sets != tt.wantSets {
+				t.Errorf("opt out updates = %d, want %d", repo.sets, tt.wantSets)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,46 @@
+package compliance_service
+
+import "strings"
+
+type Keyword string
+
+const (
+	KeywordNone  Keyword = ""
+	KeywordStop  Keyword = "STOP"
+	KeywordStart Keyword = "START"
+	KeywordHelp  Keyword = "HELP"
+)
+
+// The keywords that unambiguously mean the sender wants to opt out, opt back in or get help.
+var keywords = map[string]Keyword{
+	"STOP":        KeywordStop,
+	"STOPALL":     KeywordStop,
+	"UNSUBSCRIBE": KeywordStop,
+	"START":       KeywordStart,
+	"UNSTOP":      KeywordStart,
+	"HELP":        KeywordHelp,
+}
+
+// Twilio also opts numbers out and in on these, see
+// https://help.twilio.com/articles/223134027-Twilio-support-for-opt-out-keywords-SMS-STOP-filtering-
+// but they're ordinary replies in a group conversation ("Yes", "cancel"), so they only count as a keyword when they
+// change the sender's opt-out state. Twilio answers INFO itself and it changes nothing, so it isn't one.
+var ambiguousKeywords = map[string]Keyword{
+	"CANCEL": KeywordStop,
+	"END":    KeywordStop,
+	"QUIT":   KeywordStop,
+	"YES":    KeywordStart,
+}
+
+// ParseKeyword only matches when the whole message is the keyword (ignoring case, whitespace and trailing
+// punctuation), so "stop by the store" isn't an opt-out. ambiguous is true for the keywords in ambiguousKeywords.
+func ParseKeyword(body string) (keyword Keyword, ambiguous bool) {
+	word := strings.ToUpper(strings.TrimRight(strings.TrimSpace(body), ".!"))
+	if keyword, ok := keywords[word]; ok {
+		return keyword, false
+	}
+	if keyword, ok := ambiguousKeywords[word]; ok {
+		return keyword, true
+	}
+	return KeywordNone, false
+}
@@ -0,0 +1,43 @@
+package conversation
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+// Id builds a conversation ID from the phone numbers of its participants. The numbers are normalized to E164 and sorted
+// so that the same group always maps to the same ID, e.g. `+15554443333_+16665554444`.
+func Id(phoneNumbers []string) (string, error) {
+	if len(phoneNumbers) == 0 {
+		return "", errors.New("no phone numbers found")
+	}
+
+	e164PhoneNumbers := make([]string, len(phoneNumbers))
+	for i, phoneNumber := range phoneNumbers {
+		e164PhoneNumber, err := ToE164(phoneNumber)
+		if err != nil {
+			return "", err
+		}
+		e164PhoneNumbers[i] = e164PhoneNumber
+	}
+
+	sort.Strings(e164PhoneNumbers)
+	return strings.Join(e164PhoneNumbers, "_"), nil
+}
+
+// ToE164 normalizes a phone number to E164. We assume US for now.
+func ToE164(phoneNumber string) (string, error) {
+	number, err := libphonenumber.Parse(phoneNumber, "US")
+	if err != nil {
+		return "", errors.New("failed to parse phone number " + phoneNumber)
+	}
+	return libphonenumber.Format(number, libphonenumber.E164), nil
+}
+
+// PhoneNumbers returns the E164 phone numbers that make up a conversation ID.
+func PhoneNumbers(conversationId string) []string {
+	return strings.Split(conversationId, "_")
+}
@@ -0,0 +1,91 @@
+package conversation_lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// Dynamo keeps a lock item per conversation. Expired items are taken over by the next Acquire; the table's TTL on
+// `expires_at` just cleans them up.
+type Dynamo struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func NewDynamo(ctx context.Context) (Lock, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &Dynamo{
+		db:        db,
+		tableName: "text-agent-conversation-locks",
+	}, nil
+}
+
+func (d *Dynamo) Acquire(ctx context.Context, conversationId string, duration time.Duration) (*Lease, error) {
+	now := time.Now()
+	lease := &Lease{
+		ConversationId: conversationId,
+		Owner:          uuid.NewString(),
+		ExpiresAt:      now.Add(duration),
+	}
+
+	_, err := d.db.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tableName),
+		Item: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+			"owner":           &types.AttributeValueMemberS{Value: lease.Owner},
+			"expires_at":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", lease.ExpiresAt.Unix())},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(conversation_id) OR expires_at < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Unix())},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrHeld
+		}
+		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return lease, nil
+}
+
+func (d *Dynamo) Release(ctx context.Context, lease *Lease) error {
+	_, err := d.db.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: lease.ConversationId},
+		},
+		ConditionExpression: aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner": "owner",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: lease.Owner},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			// It isn't ours anymore; leave it to whoever has it now.
+			return nil
+		}
+		return fmt.Errorf("failed to delete item from DynamoDB: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package conversation_lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrHeld means another run has an unexpired lease on the conversation.
+var ErrHeld = errors.New("conversation lock is held")
+
+// Lease is a hold on a conversation's lock until ExpiresAt, so a run that dies without releasing it doesn't keep the
+// conversation locked.
+type Lease struct {
+	ConversationId string
+	Owner          string
+	ExpiresAt      time.Time
+}
+
+// Lock makes sure only one agent run at a time works on a conversation.
+type Lock interface {
+	// Acquire takes the conversation's lock for the duration; it returns ErrHeld if someone else holds it.
+	Acquire(ctx context.Context, conversationId string, duration time.Duration) (*Lease, error)
+	// Release gives up the lock, unless the lease expired and someone else took it in the meantime.
+	Release(ctx context.Context, lease *Lease) error
+}
@@ -0,0 +1,51 @@
+package conversation_lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Memory only locks within this process.
+type Memory struct {
+	mu     sync.Mutex
+	leases map[string]*Lease
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		leases: map[string]*Lease{},
+	}
+}
+
+func (m *Memory) Acquire(ctx context.Context, conversationId string, duration time.Duration) (*Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := m.leases[conversationId]; ok && lease.ExpiresAt.After(now) {
+		return nil, ErrHeld
+	}
+
+	lease := &Lease{
+		ConversationId: conversationId,
+		Owner:          uuid.NewString(),
+		ExpiresAt:      now.Add(duration),
+	}
+	m.leases[conversationId] = lease
+
+	return &Lease{ConversationId: lease.ConversationId, Owner: lease.Owner, ExpiresAt: lease.ExpiresAt}, nil
+}
+
+func (m *Memory) Release(ctx context.Context, lease *Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if held, ok := m.leases[lease.ConversationId]; ok && held.Owner == lease.Owner {
+		delete(m.leases, lease.ConversationId)
+	}
+
+	return nil
+}
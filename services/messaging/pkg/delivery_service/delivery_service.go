@@ -0,0 +1,111 @@
+package delivery_service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/sms_sender"
+	"github.com/rs/zerolog"
+)
+
+// DeliveryService fans an outbound message out to every participant of its conversation.
+type DeliveryService struct {
+	complianceService *compliance_service.ComplianceService
+	repo              message_repository.MessageRepository
+	smsSender         sms_sender.SmsSender
+	fromNumber        string
+	statusCallbackUrl string
+}
+
+// fromNumber is the number we text from; it's part of the conversation ID but we don't want to text ourselves.
+// statusCallbackUrl is optional; when it's set the provider reports delivery status changes to it.
+func NewDeliveryService(
+	complianceService *compliance_service.ComplianceService,
+	repo message_repository.MessageRepository,
+	smsSender sms_sender.SmsSender,
+	fromNumber string,
+	statusCallbackUrl string,
+) *DeliveryService {
+	return &DeliveryService{
+		complianceService: complianceService,
+		repo:              repo,
+		smsSender:         smsSender,
+		fromNumber:        fromNumber,
+		statusCallbackUrl: statusCallbackUrl,
+	}
+}
+
+// Deliver sends the message to each recipient and records the provider's message ID for each of them. A failure to
+// reach one recipient doesn't stop delivery to the others; it's recorded on that recipient's delivery instead.
+// Recipients that opted out are skipped.
+func (s *DeliveryService) Deliver(ctx context.Context, message *message_repository.Message) (*message_repository.Message, error) {
+	logger := zerolog.Ctx(ctx)
+
+	phoneNumbers := conversation.PhoneNumbers(message.ConversationId)
+	optedOut, err := s.complianceService.OptedOut(phoneNumbers)
+	if err != nil {
+		// Sending to someone who opted out isn't something we can risk.
+		return nil, fmt.Errorf("failed to check opt outs: %w", err)
+	}
+
+	deliveries := map[string]*message_repository.Delivery{}
+	for _, to := range phoneNumbers {
+		if to == s.fromNumber {
+			continue
+		}
+
+		delivery := &message_repository.Delivery{To: to}
+		if slices.Contains(optedOut, to) {
+			logger.Info().Str("message_id", message.Id).Str("to", to).Msg("recipient opted out, suppressing sms")
+			delivery.Status = message_repository.DeliveryStatusSuppressed
+			delivery.History = []message_repository.DeliveryStatusChange{{Status: delivery.Status, At: time.Now().UnixMilli()}}
+			deliveries[to] = delivery
+			continue
+		}
+
+		sid, err := s.smsSender.SendSms(ctx, to, message.Body, s.statusCallbackUrlFor(message))
+		if err != nil {
+			logger.Error().Err(err).Str("message_id", message.Id).Str("to", to).Msg("failed to send sms")
+			delivery.Status = message_repository.DeliveryStatusFailed
+			delivery.Error = err.Error()
+		} else {
+			delivery.ProviderMessageId = sid
+			delivery.Status = message_repository.DeliveryStatusQueued
+		}
+		delivery.History = []message_repository.DeliveryStatusChange{{Status: delivery.Status, At: time.Now().UnixMilli()}}
+		deliveries[to] = delivery
+	}
+
+	message, err = s.repo.SetDeliveries(message.Id, deliveries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record deliveries: %w", err)
+	}
+
+	return message, nil
+}
+
+// Reply texts a single participant, like the reply to HELP. Unlike a conversation's messages it isn't recorded, so
+// there's no status to track.
+func (s *DeliveryService) Reply(ctx context.Context, to, body string) error {
+	sid, err := s.smsSender.SendSms(ctx, to, body, "")
+	if err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+
+	zerolog.Ctx(ctx).Info().Str("to", to).Str("sid", sid).Msg("sent reply")
+	return nil
+}
+
+// The callback doesn't know about our message IDs, so we pass it along in the URL.
+func (s *DeliveryService) statusCallbackUrlFor(message *message_repository.Message) string {
+	if s.statusCallbackUrl == "" {
+		return ""
+	}
+	return s.statusCallbackUrl + "?message_id=" + url.QueryEscape(message.Id)
+}
@@ -0,0 +1,36 @@
+package job_queue
+
+import (
+	"context"
+	"time"
+)
+
+// MaxAttempts is how many times a job is tried before it's dead-lettered. The SQS queue's redrive policy has to match.
+const MaxAttempts = 5
+
+type JobType string
+
+const (
+	// JobTypeInvokeAgent runs the agent for the conversation's new messages.
+	JobTypeInvokeAgent JobType = "invoke_agent"
+	// JobTypeSendAnswer sends a final answer the agent gave but that didn't make it to the conversation.
+	JobTypeSendAnswer JobType = "send_answer"
+)
+
+type Job struct {
+	Id             string  `json:"id"`
+	Type           JobType `json:"type"`
+	ConversationId string  `json:"conversation_id"`
+	// MessageId and Body are the answer for JobTypeSendAnswer; MessageId is empty if the answer wasn't saved yet.
+	MessageId string `json:"message_id,omitempty"`
+	Body      string `json:"body,omitempty"`
+}
+
+// Handler processes a job; an error means the job wasn't processed and it'll be retried.
+type Handler func(ctx context.Context, job *Job) error
+
+// Queue is durable (except for the in-memory implementation); a job is handled at least once, no sooner than its
+// delay.
+type Queue interface {
+	Enqueue(ctx context.Context, job *Job, delay time.Duration) error
+}
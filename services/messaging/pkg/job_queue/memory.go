@@ -0,0 +1,95 @@
+package job_queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// retryDelay is multiplied by the number of attempts so far.
+const retryDelay = time.Second
+
+// Memory runs jobs in-process once their delay is up, retrying failed ones until MaxAttempts. Jobs are lost if the
+// process goes away, so call Wait before it does.
+type Memory struct {
+	mu          sync.Mutex
+	handler     Handler
+	deadLetters []*Job
+	pending     sync.WaitGroup
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Consume sets the handler jobs are run with; it has to be set before jobs are enqueued.
+func (m *Memory) Consume(handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handler = handler
+}
+
+func (m *Memory) Enqueue(ctx context.Context, job *Job, delay time.Duration) error {
+	if job.Id == "" {
+		job.Id = uuid.NewString()
+	}
+
+	// The job outlives the request that enqueued it, but we still want its logs to line up with the request's.
+	jobCtx := zerolog.Ctx(ctx).WithContext(context.Background())
+	m.schedule(jobCtx, job, delay, 1)
+
+	return nil
+}
+
+// Wait blocks until every job, including the ones enqueued or retried while waiting, has run.
+func (m *Memory) Wait() {
+	m.pending.Wait()
+}
+
+// DeadLetters returns the jobs that failed MaxAttempts times.
+func (m *Memory) DeadLetters() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Job{}, m.deadLetters...)
+}
+
+func (m *Memory) schedule(ctx context.Context, job *Job, delay time.Duration, attempt int) {
+	m.pending.Add(1)
+	time.AfterFunc(delay, func() {
+		defer m.pending.Done()
+		m.run(ctx, job, attempt)
+	})
+}
+
+func (m *Memory) run(ctx context.Context, job *Job, attempt int) {
+	logger := zerolog.Ctx(ctx)
+
+	m.mu.Lock()
+	handler := m.handler
+	m.mu.Unlock()
+
+	if handler == nil {
+		logger.Error().Str("job_id", job.Id).Msg("no handler for job, dropping it")
+		return
+	}
+
+	err := handler(ctx, job)
+	if err == nil {
+		return
+	}
+
+	logger.Error().Err(err).Str("job_id", job.Id).Str("conversation_id", job.ConversationId).Int("attempt", attempt).Msg("failed to handle job")
+
+	if attempt >= MaxAttempts {
+		m.mu.Lock()
+		m.deadLetters = append(m.deadLetters, job)
+		m.mu.Unlock()
+		logger.Error().Str("job_id", job.Id).Msg("job dead-lettered")
+		return
+	}
+
+	m.schedule(ctx, job, time.Duration(attempt)*retryDelay, attempt+1)
+}
@@ -0,0 +1,92 @@
+package job_queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// maxSqsDelay is the longest SQS will delay a message. Handlers check whether it's time to do the work anyway, so
+// longer delays are cut short rather than failing.
+const maxSqsDelay = 15 * time.Minute
+
+// Sqs enqueues jobs on an SQS queue; they're consumed by a Lambda with an SQS event source, which hands them to
+// HandleSqsEvent. Retries and the dead-letter queue come from the queue's redrive policy.
+type Sqs struct {
+	client   *sqs.Client
+	queueUrl string
+}
+
+func NewSqs(ctx context.Context, queueUrl string) (Queue, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	client := sqs.NewFromConfig(cfg)
+	return &Sqs{
+		client:   client,
+		queueUrl: queueUrl,
+	}, nil
+}
+
+func (s *Sqs) Enqueue(ctx context.Context, job *Job, delay time.Duration) error {
+	if job.Id == "" {
+		job.Id = uuid.NewString()
+	}
+
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	delay = min(delay, maxSqsDelay)
+	_, err = s.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(s.queueUrl),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: int32(delay.Round(time.Second).Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to SQS: %w", err)
+	}
+
+	return nil
+}
+
+// HandleSqsEvent runs the handler for each job in the batch. Failed jobs are reported back so only they're retried
+// (the event source mapping needs ReportBatchItemFailures); a message that isn't a job can't succeed, so it's logged
+// and dropped.
+func HandleSqsEvent(ctx context.Context, event events.SQSEvent, handler Handler) events.SQSEventResponse {
+	logger := zerolog.Ctx(ctx)
+
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, record := range event.Records {
+		var job Job
+		if err := json.Unmarshal([]byte(record.Body), &job); err != nil {
+			logger.Error().Err(err).Str("message_id", record.MessageId).Str("body", record.Body).Msg("failed to unmarshal job, dropping it")
+			continue
+		}
+
+		logger.Info().
+			Str("job_id", job.Id).
+			Str("type", string(job.Type)).
+			Str("conversation_id", job.ConversationId).
+			Str("receive_count", record.Attributes["ApproximateReceiveCount"]).
+			Msg("handling job")
+
+		if err := handler(ctx, &job); err != nil {
+			logger.Error().Err(err).Str("job_id", job.Id).Str("conversation_id", job.ConversationId).Msg("failed to handle job")
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+		}
+	}
+
+	return response
+}
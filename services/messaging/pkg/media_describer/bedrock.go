@@ -0,0 +1,84 @@
+package media_describer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+const describePrompt = "This file was sent to a group text conversation. Describe it in one or two sentences so that someone who can't see it knows what it shows. Include any text that's important."
+
+var imageFormats = map[string]types.ImageFormat{
+	"image/png":  types.ImageFormatPng,
+	"image/jpeg": types.ImageFormatJpeg,
+	"image/jpg":  types.ImageFormatJpeg,
+	"image/gif":  types.ImageFormatGif,
+	"image/webp": types.ImageFormatWebp,
+}
+
+// Bedrock describes images with a multimodal model; other media falls back to ContentType.
+type Bedrock struct {
+	modelId  string
+	client   *bedrockruntime.Client
+	fallback MediaDescriber
+}
+
+func NewBedrock(ctx context.Context, modelId string) (MediaDescriber, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	return &Bedrock{
+		modelId:  modelId,
+		client:   bedrockruntime.NewFromConfig(cfg),
+		fallback: NewContentType(),
+	}, nil
+}
+
+func (b *Bedrock) Describe(ctx context.Context, contentType string, data []byte) (string, error) {
+	format, ok := imageFormats[strings.ToLower(contentType)]
+	if !ok {
+		return b.fallback.Describe(ctx, contentType, data)
+	}
+
+	output, err := b.client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId: aws.String(b.modelId),
+		Messages: []types.Message{
+			{
+				Role: types.ConversationRoleUser,
+				Content: []types.ContentBlock{
+					&types.ContentBlockMemberImage{
+						Value: types.ImageBlock{
+							Format: format,
+							Source: &types.ImageSourceMemberBytes{Value: data},
+						},
+					},
+					&types.ContentBlockMemberText{Value: describePrompt},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe media: %w", err)
+	}
+
+	message, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return "", fmt.Errorf("unexpected converse output: %T", output.Output)
+	}
+
+	var description strings.Builder
+	for _, block := range message.Value.Content {
+		if text, ok := block.(*types.ContentBlockMemberText); ok {
+			description.WriteString(text.Value)
+		}
+	}
+
+	return strings.TrimSpace(description.String()), nil
+}
@@ -0,0 +1,29 @@
+package media_describer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ContentType only describes the kind of file; it doesn't look at the data. It's useful for tests and for media
+// the model can't handle.
+type ContentType struct{}
+
+func NewContentType() MediaDescriber {
+	return &ContentType{}
+}
+
+func (c *ContentType) Describe(ctx context.Context, contentType string, data []byte) (string, error) {
+	kind, _, _ := strings.Cut(contentType, "/")
+	switch kind {
+	case "image":
+		return fmt.Sprintf("An image (%s).", contentType), nil
+	case "video":
+		return fmt.Sprintf("A video (%s).", contentType), nil
+	case "audio":
+		return fmt.Sprintf("An audio recording (%s).", contentType), nil
+	default:
+		return fmt.Sprintf("A file (%s).", contentType), nil
+	}
+}
@@ -0,0 +1,8 @@
+package media_describer
+
+import "context"
+
+type MediaDescriber interface {
+	// Describe returns a short, human readable description of the media.
+	Describe(ctx context.Context, contentType string, data []byte) (string, error)
+}
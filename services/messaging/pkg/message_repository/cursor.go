@@ -0,0 +1,71 @@
+package message_repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// cursor is the `LastEvaluatedKey` of a ConversationIdIndex query. It's handed to callers as base64 encoded JSON so
+// they don't depend on the shape of our keys.
+type cursor struct {
+	Id             string `json:"id"`
+	ConversationId string `json:"conversation_id"`
+	SentAt         int64  `json:"sent_at"`
+}
+
+func encodeCursor(lastEvaluatedKey map[string]types.AttributeValue) (string, error) {
+	if len(lastEvaluatedKey) == 0 {
+		return "", nil
+	}
+
+	var c cursor
+	if v, ok := lastEvaluatedKey["id"].(*types.AttributeValueMemberS); ok {
+		c.Id = v.Value
+	}
+	if v, ok := lastEvaluatedKey["conversation_id"].(*types.AttributeValueMemberS); ok {
+		c.ConversationId = v.Value
+	}
+	if v, ok := lastEvaluatedKey["sent_at"].(*types.AttributeValueMemberN); ok {
+		sentAt, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse sent_at: %w", err)
+		}
+		c.SentAt = sentAt
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the `ExclusiveStartKey` for the cursor. The cursor has to belong to the conversation being
+// queried.
+func decodeCursor(encoded, conversationId string) (map[string]types.AttributeValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	if c.ConversationId != conversationId {
+		return nil, errors.New("cursor is for a different conversation")
+	}
+
+	return map[string]types.AttributeValue{
+		"id":              &types.AttributeValueMemberS{Value: c.Id},
+		"conversation_id": &types.AttributeValueMemberS{Value: c.ConversationId},
+		"sent_at":         &types.AttributeValueMemberN{Value: strconv.FormatInt(c.SentAt, 10)},
+	}, nil
+}
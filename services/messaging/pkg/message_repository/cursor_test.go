@@ -0,0 +1,42 @@
+package message_repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestCursor(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	lastEvaluatedKey := map[string]types.AttributeValue{
+		"id":              &types.AttributeValueMemberS{Value: "m1"},
+		"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		"sent_at":         &types.AttributeValueMemberN{Value: "1755831600000"},
+	}
+
+	encoded, err := encodeCursor(lastEvaluatedKey)
+	if err != nil {
+		t.Fatalf("encodeCursor() error = %v", err)
+	}
+
+	startKey, err := decodeCursor(encoded, conversationId)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(startKey, lastEvaluatedKey) {
+		t.Errorf("decodeCursor() = %v, want %v", startKey, lastEvaluatedKey)
+	}
+
+	if _, err := decodeCursor(encoded, "+15555550100_+15555550199"); err == nil {
+		t.Errorf("decodeCursor() for a different conversation succeeded, want an error")
+	}
+	if _, err := decodeCursor("not a cursor", conversationId); err == nil {
+		t.Errorf("decodeCursor() of garbage succeeded, want an error")
+	}
+
+	// The last page has no cursor.
+	if encoded, err := encodeCursor(nil); err != nil || encoded != "" {
+		t.Errorf("encodeCursor(nil) = %q, %v, want no cursor", encoded, err)
+	}
+}
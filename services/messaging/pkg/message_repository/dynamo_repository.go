@@ -2,7 +2,10 @@ package message_repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -31,34 +34,119 @@ func New(ctx context.Context) (MessageRepository, error) {
 	}, nil
 }
 
-func (r *DynamoRepository) CreateMessage(conversationId, from, body string) (*Message, error) {
+// externalIdNamespace keeps the IDs we derive from external IDs from colliding with other name based UUIDs.
+var externalIdNamespace = uuid.MustParse("6f1c7a52-3d0e-4f8b-9a61-2c4d8e5b7f90")
+
+var errMessageExists = errors.New("message already exists")
+
+func (r *DynamoRepository) CreateMessage(conversationId, from, body string, attachments []*Attachment) (*Message, error) {
 	message := &Message{
 		Id:             uuid.NewString(),
 		ConversationId: conversationId,
 		From:           from,
 		Body:           body,
 		SentAt:         time.Now().UnixMilli(),
+		Attachments:    attachments,
 	}
 
-	av, err := attributevalue.MarshalMap(message)
+	if err := r.putMessage(message); err != nil {
+		return nil, err
+	}
+
+	message, err := r.GetMessage(message.Id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal message: %w", err)
+		return nil, fmt.Errorf("failed to get message from DynamoDB: %w", err)
 	}
 
-	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+	return message, nil
+}
+
+// The message's ID is derived from the conversation and external ID, so the conditional write is what makes the
+// external ID unique.
+func (r *DynamoRepository) CreateMessageWithExternalId(conversationId, externalId, from, body string, attachments []*Attachment) (*Message, bool, error) {
+	if externalId == "" {
+		message, err := r.CreateMessage(conversationId, from, body, attachments)
+		return message, false, err
+	}
+
+	message := &Message{
+		Id:             externalMessageId(conversationId, externalId),
+		ConversationId: conversationId,
+		From:           from,
+		Body:           body,
+		SentAt:         time.Now().UnixMilli(),
+		Attachments:    attachments,
+		ExternalId:     externalId,
+	}
+
+	err := r.putMessage(message)
+	duplicate := errors.Is(err, errMessageExists)
+	if err != nil && !duplicate {
+		return nil, false, err
+	}
+
+	message, err = r.GetMessage(message.Id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get message from DynamoDB: %w", err)
+	}
+
+	return message, duplicate, nil
+}
+
+func (r *DynamoRepository) GetMessageByExternalId(conversationId, externalId string) (*Message, error) {
+	if externalId == "" {
+		return nil, nil
+	}
+
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
 		TableName: aws.String(r.tableName),
-		Item:      av,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: externalMessageId(conversationId, externalId)},
+		},
+		ConsistentRead: aws.Bool(true),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
 	}
 
-	message, err = r.GetMessage(message.Id)
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var message Message
+	err = attributevalue.UnmarshalMap(result.Item, &message)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get message from DynamoDB: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
-	return message, nil
+	return &message, nil
+}
+
+// externalMessageId is the ID of the conversation's message with the external ID.
+func externalMessageId(conversationId, externalId string) string {
+	return uuid.NewSHA1(externalIdNamespace, []byte(conversationId+"\x00"+externalId)).String()
+}
+
+func (r *DynamoRepository) putMessage(message *Message) error {
+	av, err := attributevalue.MarshalMap(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return errMessageExists
+		}
+		return fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return nil
 }
 
 func (r *DynamoRepository) GetMessage(id string) (*Message, error) {
@@ -73,7 +161,7 @@ func (r *DynamoRepository) GetMessage(id string) (*Message, error) {
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("task not found with ID: %s", id)
+		return nil, fmt.Errorf("message not found with ID: %s", id)
 	}
 
 	var message Message
@@ -86,36 +174,191 @@ func (r *DynamoRepository) GetMessage(id string) (*Message, error) {
 }
 
 func (r *DynamoRepository) ListRecentMessagesByConversation(conversationID string) ([]*Message, error) {
+	page, err := r.ListMessages(context.Background(), conversationID, ListMessagesOptions{
+		PageSize:    DefaultPageSize,
+		NewestFirst: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := page.Messages
+
+	// Reverse the messages so that the oldest message comes first.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+func (r *DynamoRepository) ListMessages(ctx context.Context, conversationID string, opts ListMessagesOptions) (*MessagePage, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	keyCondition := "conversation_id = :convId"
+	values := map[string]types.AttributeValue{
+		":convId": &types.AttributeValueMemberS{Value: conversationID},
+	}
+	switch {
+	case opts.After > 0 && opts.Before > 0:
+		keyCondition += " AND sent_at BETWEEN :after AND :before"
+		values[":after"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.After, 10)}
+		values[":before"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.Before-1, 10)}
+	case opts.After > 0:
+		keyCondition += " AND sent_at >= :after"
+		values[":after"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.After, 10)}
+	case opts.Before > 0:
+		keyCondition += " AND sent_at < :before"
+		values[":before"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(opts.Before, 10)}
+	}
+
 	input := &dynamodb.QueryInput{
-		TableName:              aws.String(r.tableName),
-		IndexName:              aws.String("ConversationIdIndex"),
-		KeyConditionExpression: aws.String("conversation_id = :convId"),
+		TableName:                 aws.String(r.tableName),
+		IndexName:                 aws.String("ConversationIdIndex"),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(!opts.NewestFirst), // true for ascending (oldest first), false for descending (newest first)
+		Limit:                     aws.Int32(pageSize),
+	}
+
+	if opts.Cursor != "" {
+		startKey, err := decodeCursor(opts.Cursor, conversationID)
+		if err != nil {
+			return nil, err
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	result, err := r.db.Query(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+	}
+
+	messages := []*Message{}
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal messages: %w", err)
+	}
+
+	nextCursor, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessagePage{
+		Messages:   messages,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *DynamoRepository) SetDeliveries(id string, deliveries map[string]*Delivery) (*Message, error) {
+	av, err := attributevalue.Marshal(deliveries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deliveries: %w", err)
+	}
+
+	_, err = r.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET deliveries = :deliveries"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":convId": &types.AttributeValueMemberS{Value: conversationID},
+			":deliveries": av,
 		},
-		ScanIndexForward: aws.Bool(false), // true for ascending (oldest first), false for descending (newest first)
-		Limit:            aws.Int32(20),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
 	}
 
-	result, err := r.db.Query(context.Background(), input)
+	message, err := r.GetMessage(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+		return nil, fmt.Errorf("failed to get message from DynamoDB: %w", err)
 	}
 
-	if len(result.Items) == 0 {
-		return []*Message{}, nil
+	return message, nil
+}
+
+// UpdateDeliveryStatus sets the current status of the delivery to `to` and adds it to the delivery's history. The
+// provider message ID has to match the one recorded when the message was sent. A status that isn't ahead of the
+// delivery's current one (see DeliveryStatus.Rank) is a late callback; it's ignored and the message is returned as is.
+func (r *DynamoRepository) UpdateDeliveryStatus(id, to, providerMessageId string, status DeliveryStatus, errorCode string) (*Message, error) {
+	before := statusesBefore(status)
+	if len(before) == 0 {
+		// Nothing comes before it, so there's nothing it could move the delivery forward from.
+		return r.deliveryMessage(id, to, providerMessageId)
 	}
 
-	var messages []*Message
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &messages)
+	change, err := attributevalue.Marshal([]DeliveryStatusChange{{
+		Status:    status,
+		ErrorCode: errorCode,
+		At:        time.Now().UnixMilli(),
+	}})
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal messages: %w", err)
+		return nil, fmt.Errorf("failed to marshal status change: %w", err)
 	}
 
-	// Reverse the messages so that the oldest message comes first.
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+	values := map[string]types.AttributeValue{
+		":status":            &types.AttributeValueMemberS{Value: string(status)},
+		":errorCode":         &types.AttributeValueMemberS{Value: errorCode},
+		":empty":             &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		":change":            change,
+		":providerMessageId": &types.AttributeValueMemberS{Value: providerMessageId},
+	}
+	placeholders := make([]string, len(before))
+	for i, s := range before {
+		placeholders[i] = ":before" + strconv.Itoa(i)
+		values[placeholders[i]] = &types.AttributeValueMemberS{Value: string(s)}
 	}
 
-	return messages, nil
+	_, err = r.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET deliveries.#to.#status = :status, deliveries.#to.error_code = :errorCode, deliveries.#to.history = list_append(if_not_exists(deliveries.#to.history, :empty), :change)"),
+		ConditionExpression: aws.String("deliveries.#to.provider_message_id = :providerMessageId AND deliveries.#to.#status IN (" + strings.Join(placeholders, ", ") + ")"),
+		ExpressionAttributeNames: map[string]string{
+			"#to":     to,
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return r.deliveryMessage(id, to, providerMessageId)
+		}
+		return nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	message, err := r.GetMessage(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message from DynamoDB: %w", err)
+	}
+
+	return message, nil
+}
+
+// deliveryMessage returns the message if it has a delivery to `to` with the provider message ID; it's how a status
+// update that didn't apply tells a late callback from one for a delivery we don't know about.
+func (r *DynamoRepository) deliveryMessage(id, to, providerMessageId string) (*Message, error) {
+	message, err := r.GetMessage(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message from DynamoDB: %w", err)
+	}
+
+	delivery, ok := message.Deliveries[to]
+	if !ok || delivery.ProviderMessageId != providerMessageId {
+		return nil, fmt.Errorf("message %s has no delivery to %s with provider message ID %s", id, to, providerMessageId)
+	}
+
+	return message, nil
 }
@@ -1,6 +1,17 @@
 package message_repository
 
+import "context"
+
 type MessageRepository interface {
-	CreateMessage(conversationId, from, body string) (*Message, error)
+	CreateMessage(conversationId, from, body string, attachments []*Attachment) (*Message, error)
+	// CreateMessageWithExternalId creates the message unless the conversation already has one with the external ID, in
+	// which case that one is returned and duplicate is true.
+	CreateMessageWithExternalId(conversationId, externalId, from, body string, attachments []*Attachment) (message *Message, duplicate bool, err error)
+	GetMessage(id string) (*Message, error)
+	// GetMessageByExternalId returns nil if the conversation doesn't have a message with the external ID.
+	GetMessageByExternalId(conversationId, externalId string) (*Message, error)
 	ListRecentMessagesByConversation(conversationID string) ([]*Message, error)
+	ListMessages(ctx context.Context, conversationID string, opts ListMessagesOptions) (*MessagePage, error)
+	SetDeliveries(id string, deliveries map[string]*Delivery) (*Message, error)
+	UpdateDeliveryStatus(id, to, providerMessageId string, status DeliveryStatus, errorCode string) (*Message, error)
 }
@@ -1,9 +1,137 @@
 package message_repository
 
+import "sort"
+
+// FromAssistant is the `From` of messages written by the agent.
+const FromAssistant = "Assistant"
+
 type Message struct {
-	Id             string `json:"id" dynamodbav:"id"`
-	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
-	Body           string `json:"body" dynamodbav:"body"`
-	From           string `json:"from" dynamodbav:"from"`
-	SentAt         int64  `json:"sent_at" dynamodbav:"sent_at"` // UNIX timestamp in milliseconds
+	Id             string        `json:"id" dynamodbav:"id"`
+	ConversationId string        `json:"conversation_id" dynamodbav:"conversation_id"`
+	Body           string        `json:"body" dynamodbav:"body"`
+	From           string        `json:"from" dynamodbav:"from"`
+	SentAt         int64         `json:"sent_at" dynamodbav:"sent_at"` // UNIX timestamp in milliseconds
+	Attachments    []*Attachment `json:"attachments,omitempty" dynamodbav:"attachments,omitempty"`
+	// ExternalId identifies the message outside of here (e.g. a Twilio MessageSid or a client's idempotency key); a
+	// conversation only ever has one message per external ID.
+	ExternalId string `json:"external_id,omitempty" dynamodbav:"external_id,omitempty"`
+	// Deliveries is only set for outbound messages and is keyed by the recipient's E164 phone number.
+	Deliveries map[string]*Delivery `json:"deliveries,omitempty" dynamodbav:"deliveries,omitempty"`
+}
+
+// Attachment is a file (e.g. MMS media) that was sent with a message.
+type Attachment struct {
+	Id          string `json:"id" dynamodbav:"id"`
+	ContentType string `json:"content_type" dynamodbav:"content_type"`
+	// Key is where the file is kept in the blob store.
+	Key string `json:"key" dynamodbav:"key"`
+	// Url points at the stored file, e.g. `s3://bucket/key`.
+	Url string `json:"url" dynamodbav:"url"`
+	// Description is AI generated.
+	Description string `json:"description,omitempty" dynamodbav:"description,omitempty"`
 }
+
+// https://www.twilio.com/docs/messaging/api/message-resource#message-status-values
+type DeliveryStatus string
+
+const (
+	DeliveryStatusQueued      DeliveryStatus = "queued"
+	DeliveryStatusSent        DeliveryStatus = "sent"
+	DeliveryStatusDelivered   DeliveryStatus = "delivered"
+	DeliveryStatusUndelivered DeliveryStatus = "undelivered"
+	DeliveryStatusFailed      DeliveryStatus = "failed"
+	// DeliveryStatusSuppressed is ours, not Twilio's; the recipient opted out so we didn't send it.
+	DeliveryStatusSuppressed DeliveryStatus = "suppressed"
+)
+
+// deliveryStatusRanks orders the statuses a delivery moves through. Twilio doesn't guarantee status callbacks arrive in
+// order, so a delivery only ever moves to a status of a higher rank.
+var deliveryStatusRanks = map[DeliveryStatus]int{
+	DeliveryStatusQueued:      1,
+	DeliveryStatusSent:        2,
+	DeliveryStatusDelivered:   3,
+	DeliveryStatusUndelivered: 3,
+	DeliveryStatusFailed:      3,
+	DeliveryStatusSuppressed:  3,
+}
+
+// ParseDeliveryStatus maps a Twilio message status onto the ones we track. ok is false for statuses we don't track
+// (e.g. `receiving`), which should be ignored.
+func ParseDeliveryStatus(value string) (status DeliveryStatus, ok bool) {
+	switch value {
+	case "accepted", "scheduled", "queued", "sending":
+		return DeliveryStatusQueued, true
+	case "sent":
+		return DeliveryStatusSent, true
+	case "delivered", "read":
+		// `read` is only reported for WhatsApp and RCS, and a message that was read was delivered.
+		return DeliveryStatusDelivered, true
+	case "undelivered":
+		return DeliveryStatusUndelivered, true
+	case "failed", "canceled":
+		return DeliveryStatusFailed, true
+	}
+	return "", false
+}
+
+// Rank is the status's place in the order deliveries move through; 0 if it isn't one we track.
+func (s DeliveryStatus) Rank() int {
+	return deliveryStatusRanks[s]
+}
+
+// statusesBefore are the statuses of a lower rank than status, sorted.
+func statusesBefore(status DeliveryStatus) []DeliveryStatus {
+	before := []DeliveryStatus{}
+	for s, rank := range deliveryStatusRanks {
+		if rank < status.Rank() {
+			before = append(before, s)
+		}
+	}
+	sort.Slice(before, func(i, j int) bool { return before[i] < before[j] })
+	return before
+}
+
+// Delivery tracks an outbound message to a single recipient.
+type Delivery struct {
+	To                string                 `json:"to" dynamodbav:"to"`
+	ProviderMessageId string                 `json:"provider_message_id,omitempty" dynamodbav:"provider_message_id,omitempty"` // e.g. a Twilio SID
+	Status            DeliveryStatus         `json:"status" dynamodbav:"status"`
+	ErrorCode         string                 `json:"error_code,omitempty" dynamodbav:"error_code,omitempty"`
+	Error             string                 `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	History           []DeliveryStatusChange `json:"history,omitempty" dynamodbav:"history,omitempty"`
+}
+
+type DeliveryStatusChange struct {
+	Status    DeliveryStatus `json:"status" dynamodbav:"status"`
+	ErrorCode string         `json:"error_code,omitempty" dynamodbav:"error_code,omitempty"`
+	At        int64          `json:"at" dynamodbav:"at"` // UNIX timestamp in milliseconds
+}
+
+// Failed is true when the message didn't (and won't) reach the recipient.
+func (d *Delivery) Failed() bool {
+	return d.Status == DeliveryStatusFailed || d.Status == DeliveryStatusUndelivered
+}
+
+type ListMessagesOptions struct {
+	// Cursor continues from a previous page; it's opaque to callers.
+	Cursor string
+	// After and Before bound `sent_at` (UNIX milliseconds); After is inclusive and Before is exclusive. Zero means
+	// unbounded.
+	After  int64
+	Before int64
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize.
+	PageSize int32
+	// NewestFirst reverses the default oldest first order.
+	NewestFirst bool
+}
+
+type MessagePage struct {
+	Messages []*Message `json:"messages"`
+	// NextCursor is empty when there are no more messages.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
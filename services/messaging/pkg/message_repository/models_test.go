@@ -0,0 +1,51 @@
+package message_repository
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   DeliveryStatus
+		wantOk bool
+	}{
+		{"accepted", DeliveryStatusQueued, true},
+		{"queued", DeliveryStatusQueued, true},
+		{"sending", DeliveryStatusQueued, true},
+		{"sent", DeliveryStatusSent, true},
+		{"delivered", DeliveryStatusDelivered, true},
+		{"read", DeliveryStatusDelivered, true},
+		{"undelivered", DeliveryStatusUndelivered, true},
+		{"failed", DeliveryStatusFailed, true},
+		{"canceled", DeliveryStatusFailed, true},
+		{"receiving", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseDeliveryStatus(tt.value)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("ParseDeliveryStatus(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestStatusesBefore(t *testing.T) {
+	tests := []struct {
+		status DeliveryStatus
+		want   []DeliveryStatus
+	}{
+		{DeliveryStatusQueued, []DeliveryStatus{}},
+		{DeliveryStatusSent, []DeliveryStatus{DeliveryStatusQueued}},
+		{DeliveryStatusDelivered, []DeliveryStatus{DeliveryStatusQueued, DeliveryStatusSent}},
+		{DeliveryStatusFailed, []DeliveryStatus{DeliveryStatusQueued, DeliveryStatusSent}},
+	}
+
+	for _, tt := range tests {
+		if got := statusesBefore(tt.status); !slices.Equal(got, tt.want) {
+			t.Errorf("statusesBefore(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package opt_out_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (OptOutRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-opt-outs",
+	}, nil
+}
+
+func (r *DynamoRepository) GetOptOuts(phoneNumbers []string) (map[string]*OptOut, error) {
+	optOuts := map[string]*OptOut{}
+	if len(phoneNumbers) == 0 {
+		return optOuts, nil
+	}
+
+	// Conversations are small, so we don't bother splitting into batches of 100 keys.
+	keys := make([]map[string]types.AttributeValue, len(phoneNumbers))
+	for i, phoneNumber := range phoneNumbers {
+		keys[i] = map[string]types.AttributeValue{
+			"phone_number": &types.AttributeValueMemberS{Value: phoneNumber},
+		}
+	}
+
+	requestItems := map[string]types.KeysAndAttributes{
+		r.tableName: {Keys: keys},
+	}
+	for len(requestItems) > 0 {
+		result, err := r.db.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch get items from DynamoDB: %w", err)
+		}
+
+		var page []*OptOut
+		err = attributevalue.UnmarshalListOfMaps(result.Responses[r.tableName], &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal opt outs: %w", err)
+		}
+		for _, optOut := range page {
+			optOuts[optOut.PhoneNumber] = optOut
+		}
+
+		requestItems = result.UnprocessedKeys
+	}
+
+	return optOuts, nil
+}
+
+func (r *DynamoRepository) SetOptedOut(phoneNumber string, optedOut bool, keyword string) (*OptOut, error) {
+	optOut := &OptOut{
+		PhoneNumber: phoneNumber,
+		OptedOut:    optedOut,
+		Keyword:     keyword,
+		UpdatedAt:   time.Now().UnixMilli(),
+	}
+
+	av, err := attributevalue.MarshalMap(optOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal opt out: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return optOut, nil
+}
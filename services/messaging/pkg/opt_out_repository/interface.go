@@ -0,0 +1,7 @@
+package opt_out_repository
+
+type OptOutRepository interface {
+	// GetOptOuts returns the state of the numbers that have one; numbers that never texted a keyword are missing.
+	GetOptOuts(phoneNumbers []string) (map[string]*OptOut, error)
+	SetOptedOut(phoneNumber string, optedOut bool, keyword string) (*OptOut, error)
+}
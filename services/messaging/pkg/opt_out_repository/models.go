@@ -0,0 +1,10 @@
+package opt_out_repository
+
+// OptOut is the carrier opt-out state of a phone number. It applies to every conversation the number is in.
+type OptOut struct {
+	PhoneNumber string `json:"phone_number" dynamodbav:"phone_number"` // E164
+	OptedOut    bool   `json:"opted_out" dynamodbav:"opted_out"`
+	// Keyword is what the participant texted to change their state, e.g. `STOP`.
+	Keyword   string `json:"keyword" dynamodbav:"keyword"`
+	UpdatedAt int64  `json:"updated_at" dynamodbav:"updated_at"` // UNIX timestamp in milliseconds
+}
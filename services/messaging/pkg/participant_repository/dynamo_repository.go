@@ -0,0 +1,100 @@
+package participant_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (ParticipantRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-participants",
+	}, nil
+}
+
+func (r *DynamoRepository) GetParticipant(conversationId, phoneNumber string) (*Participant, error) {
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+			"phone_number":    &types.AttributeValueMemberS{Value: phoneNumber},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var participant Participant
+	err = attributevalue.UnmarshalMap(result.Item, &participant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal participant: %w", err)
+	}
+
+	return &participant, nil
+}
+
+func (r *DynamoRepository) ListParticipantsByConversation(conversationId string) ([]*Participant, error) {
+	result, err := r.db.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("conversation_id = :convId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":convId": &types.AttributeValueMemberS{Value: conversationId},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+	}
+
+	if len(result.Items) == 0 {
+		return []*Participant{}, nil
+	}
+
+	var participants []*Participant
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &participants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal participants: %w", err)
+	}
+
+	return participants, nil
+}
+
+func (r *DynamoRepository) SaveParticipant(participant *Participant) (*Participant, error) {
+	participant.UpdatedAt = time.Now().UnixMilli()
+
+	av, err := attributevalue.MarshalMap(participant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal participant: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return participant, nil
+}
@@ -0,0 +1,9 @@
+package participant_repository
+
+type ParticipantRepository interface {
+	// GetParticipant returns nil if the participant hasn't been saved.
+	GetParticipant(conversationId, phoneNumber string) (*Participant, error)
+	ListParticipantsByConversation(conversationId string) ([]*Participant, error)
+	// SaveParticipant creates or replaces the participant.
+	SaveParticipant(participant *Participant) (*Participant, error)
+}
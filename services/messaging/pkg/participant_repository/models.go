@@ -0,0 +1,31 @@
+package participant_repository
+
+import "time"
+
+// Participant is a person in a conversation. The same phone number can go by different names in different
+// conversations.
+type Participant struct {
+	ConversationId string   `json:"conversation_id" dynamodbav:"conversation_id"`
+	PhoneNumber    string   `json:"phone_number" dynamodbav:"phone_number"` // E164
+	DisplayName    string   `json:"display_name,omitempty" dynamodbav:"display_name,omitempty"`
+	Aliases        []string `json:"aliases,omitempty" dynamodbav:"aliases,omitempty"`
+	// Timezone is an IANA name, e.g. `America/Denver`.
+	Timezone  string `json:"timezone,omitempty" dynamodbav:"timezone,omitempty"`
+	UpdatedAt int64  `json:"updated_at,omitempty" dynamodbav:"updated_at,omitempty"` // UNIX timestamp in milliseconds
+}
+
+// ConversationLocation is the conversation's timezone: that of the first participant who's set one, or else
+// defaultLocation. Participants are listed by phone number, so it's the same one every time. Due dates and reminders
+// both go by it, so they agree on what day it is.
+func ConversationLocation(participants []*Participant, defaultLocation *time.Location) *time.Location {
+	for _, participant := range participants {
+		if participant.Timezone == "" {
+			continue
+		}
+		// Timezones are validated when they're saved, so this shouldn't fail.
+		if location, err := time.LoadLocation(participant.Timezone); err == nil {
+			return location
+		}
+	}
+	return defaultLocation
+}
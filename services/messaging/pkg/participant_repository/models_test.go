@@ -0,0 +1,45 @@
+package participant_repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConversationLocation(t *testing.T) {
+	tests := []struct {
+		name         string
+		participants []*Participant
+		want         string
+	}{
+		{
+			name: "first participant with a timezone",
+			participants: []*Participant{
+				{PhoneNumber: "+15555550101"},
+				{PhoneNumber: "+15555550102", Timezone: "America/Denver"},
+				{PhoneNumber: "+15555550103", Timezone: "America/New_York"},
+			},
+			want: "America/Denver",
+		},
+		{
+			name: "invalid timezones are skipped",
+			participants: []*Participant{
+				{PhoneNumber: "+15555550101", Timezone: "Mountain"},
+				{PhoneNumber: "+15555550102", Timezone: "America/New_York"},
+			},
+			want: "America/New_York",
+		},
+		{
+			name:         "default",
+			participants: []*Participant{{PhoneNumber: "+15555550101"}},
+			want:         "UTC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConversationLocation(tt.participants, time.UTC); got.String() != tt.want {
+				t.Errorf("ConversationLocation() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pending_run_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Other Lambda instances can be adding to the same pending run; we re-read and retry this many times.
+const maxConflictRetries = 5
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (PendingRunRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-pending-runs",
+	}, nil
+}
+
+// AddMessages only appends the messages the run doesn't have yet; the condition catches one that was added since we
+// looked, and then we look again.
+func (r *DynamoRepository) AddMessages(conversationId string, messageIds []string, at int64) (*PendingRun, error) {
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		pendingRun, err := r.GetPendingRun(conversationId)
+		if err != nil {
+			return nil, err
+		}
+
+		newMessageIds := messageIds
+		if pendingRun != nil {
+			newMessageIds = []string{}
+			for _, messageId := range messageIds {
+				if !slices.Contains(pendingRun.MessageIds, messageId) && !slices.Contains(newMessageIds, messageId) {
+					newMessageIds = append(newMessageIds, messageId)
+				}
+			}
+			if len(newMessageIds) == 0 {
+				return pendingRun, nil
+			}
+		}
+
+		pendingRun, err = r.appendMessages(conversationId, newMessageIds, at)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return pendingRun, nil
+	}
+
+	return nil, fmt.Errorf("failed to add messages to pending run after %d attempts", maxConflictRetries)
+}
+
+// appendMessages returns ErrConflict if the run already has one of the messages.
+func (r *DynamoRepository) appendMessages(conversationId string, messageIds []string, at int64) (*PendingRun, error) {
+	messageIdValues := []types.AttributeValue{}
+	conditions := []string{}
+	values := map[string]types.AttributeValue{
+		":empty": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		":at":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", at)},
+		":one":   &types.AttributeValueMemberN{Value: "1"},
+	}
+	for i, messageId := range messageIds {
+		messageIdValues = append(messageIdValues, &types.AttributeValueMemberS{Value: messageId})
+		placeholder := fmt.Sprintf(":messageId%d", i)
+		conditions = append(conditions, "NOT contains(message_ids, "+placeholder+")")
+		values[placeholder] = &types.AttributeValueMemberS{Value: messageId}
+	}
+	values[":messageIds"] = &types.AttributeValueMemberL{Value: messageIdValues}
+
+	result, err := r.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		},
+		UpdateExpression:          aws.String("SET message_ids = list_append(if_not_exists(message_ids, :empty), :messageIds), first_message_at = if_not_exists(first_message_at, :at), last_message_at = :at ADD version :one"),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrConflict
+		}
+		return nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	var pendingRun PendingRun
+	err = attributevalue.UnmarshalMap(result.Attributes, &pendingRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending run: %w", err)
+	}
+
+	return &pendingRun, nil
+}
+
+func (r *DynamoRepository) GetPendingRun(conversationId string) (*PendingRun, error) {
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var pendingRun PendingRun
+	err = attributevalue.UnmarshalMap(result.Item, &pendingRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending run: %w", err)
+	}
+
+	return &pendingRun, nil
+}
+
+func (r *DynamoRepository) ClaimPendingRun(conversationId string, version int64) error {
+	_, err := r.db.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		},
+		ConditionExpression: aws.String("version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", version)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to delete item from DynamoDB: %w", err)
+	}
+
+	return nil
+}
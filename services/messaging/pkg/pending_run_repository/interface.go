@@ -0,0 +1,17 @@
+package pending_run_repository
+
+import "errors"
+
+// ErrConflict means the pending run changed (or was claimed) since it was read.
+var ErrConflict = errors.New("pending run was updated concurrently")
+
+type PendingRunRepository interface {
+	// AddMessages adds the messages to the conversation's pending run, starting one if there isn't one. Messages the
+	// run already has aren't added again.
+	AddMessages(conversationId string, messageIds []string, at int64) (*PendingRun, error)
+	// GetPendingRun returns nil if the conversation doesn't have a pending run.
+	GetPendingRun(conversationId string) (*PendingRun, error)
+	// ClaimPendingRun removes the pending run if it's still at the version it was read at; it returns ErrConflict
+	// otherwise.
+	ClaimPendingRun(conversationId string, version int64) error
+}
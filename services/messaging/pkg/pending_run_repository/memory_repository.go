@@ -0,0 +1,78 @@
+package pending_run_repository
+
+import (
+	"slices"
+	"sync"
+)
+
+// Memory keeps pending runs in this process; it's for running the coalesce service locally and in tests.
+type Memory struct {
+	mu          sync.Mutex
+	pendingRuns map[string]*PendingRun
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		pendingRuns: map[string]*PendingRun{},
+	}
+}
+
+func (m *Memory) AddMessages(conversationId string, messageIds []string, at int64) (*PendingRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pendingRun, ok := m.pendingRuns[conversationId]
+	if !ok {
+		pendingRun = &PendingRun{
+			ConversationId: conversationId,
+			MessageIds:     []string{},
+			FirstMessageAt: at,
+		}
+		m.pendingRuns[conversationId] = pendingRun
+	}
+
+	added := false
+	for _, messageId := range messageIds {
+		if !slices.Contains(pendingRun.MessageIds, messageId) {
+			pendingRun.MessageIds = append(pendingRun.MessageIds, messageId)
+			added = true
+		}
+	}
+	if added {
+		pendingRun.LastMessageAt = at
+		pendingRun.Version++
+	}
+
+	return copyPendingRun(pendingRun), nil
+}
+
+func (m *Memory) GetPendingRun(conversationId string) (*PendingRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pendingRun, ok := m.pendingRuns[conversationId]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyPendingRun(pendingRun), nil
+}
+
+func (m *Memory) ClaimPendingRun(conversationId string, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pendingRun, ok := m.pendingRuns[conversationId]
+	if !ok || pendingRun.Version != version {
+		return ErrConflict
+	}
+
+	delete(m.pendingRuns, conversationId)
+	return nil
+}
+
+func copyPendingRun(pendingRun *PendingRun) *PendingRun {
+	copied := *pendingRun
+	copied.MessageIds = append([]string{}, pendingRun.MessageIds...)
+	return &copied
+}
@@ -0,0 +1,11 @@
+package pending_run_repository
+
+// PendingRun is the messages a conversation received since the agent last ran for it.
+type PendingRun struct {
+	ConversationId string   `json:"conversation_id" dynamodbav:"conversation_id"`
+	MessageIds     []string `json:"message_ids" dynamodbav:"message_ids"`
+	FirstMessageAt int64    `json:"first_message_at" dynamodbav:"first_message_at"` // UNIX timestamp in milliseconds
+	LastMessageAt  int64    `json:"last_message_at" dynamodbav:"last_message_at"`   // UNIX timestamp in milliseconds
+	// Version is bumped with every message, so a claim fails if a message arrived after the run was read.
+	Version int64 `json:"version" dynamodbav:"version"`
+}
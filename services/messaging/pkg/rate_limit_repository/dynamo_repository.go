@@ -0,0 +1,86 @@
+package rate_limit_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (RateLimitRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-rate-limits",
+	}, nil
+}
+
+func (r *DynamoRepository) GetLimit(conversationId string) (*Limit, error) {
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var limit Limit
+	err = attributevalue.UnmarshalMap(result.Item, &limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal limit: %w", err)
+	}
+
+	return &limit, nil
+}
+
+func (r *DynamoRepository) SaveLimit(limit *Limit) error {
+	readVersion := limit.Version
+	updated := *limit
+	updated.Version++
+
+	av, err := attributevalue.MarshalMap(updated)
+	if err != nil {
+		return fmt.Errorf("failed to marshal limit: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(conversation_id) OR version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", readVersion)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrConflict
+		}
+		return fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	limit.Version = updated.Version
+	return nil
+}
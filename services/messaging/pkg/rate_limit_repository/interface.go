@@ -0,0 +1,14 @@
+package rate_limit_repository
+
+import "errors"
+
+// ErrConflict means the limit changed since it was read; read it again and retry.
+var ErrConflict = errors.New("rate limit was updated concurrently")
+
+type RateLimitRepository interface {
+	// GetLimit returns nil if the conversation doesn't have a limit yet.
+	GetLimit(conversationId string) (*Limit, error)
+	// SaveLimit saves the limit if it's still at the version it was read at, then bumps the version. It returns
+	// ErrConflict otherwise.
+	SaveLimit(limit *Limit) error
+}
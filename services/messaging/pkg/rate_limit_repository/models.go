@@ -0,0 +1,15 @@
+package rate_limit_repository
+
+// Limit is a conversation's rate limit and budget state.
+type Limit struct {
+	ConversationId string  `json:"conversation_id" dynamodbav:"conversation_id"`
+	Tokens         float64 `json:"tokens" dynamodbav:"tokens"`
+	RefilledAt     int64   `json:"refilled_at" dynamodbav:"refilled_at"` // UNIX timestamp in milliseconds
+	Day            string  `json:"day" dynamodbav:"day"`                 // YYYY-MM-DD (UTC) that DayInvocations counts.
+	DayInvocations int     `json:"day_invocations" dynamodbav:"day_invocations"`
+	// LimitedSince is when we first turned the conversation away since its last allowed invocation; 0 if we haven't.
+	LimitedSince int64 `json:"limited_since" dynamodbav:"limited_since"` // UNIX timestamp in milliseconds
+	NoticeSent   bool  `json:"notice_sent" dynamodbav:"notice_sent"`
+	// Version guards against concurrent updates from other Lambda instances.
+	Version int64 `json:"version" dynamodbav:"version"`
+}
@@ -0,0 +1,77 @@
+package rate_limit_service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Behavior is what happens to an invocation that's over the limit.
+type Behavior string
+
+const (
+	// BehaviorDrop skips the invocation; the agent won't look at the message unless a later one brings it up.
+	BehaviorDrop Behavior = "drop"
+	// BehaviorDefer puts the invocation off until the conversation is allowed again, when it's told to catch up on
+	// what was skipped.
+	BehaviorDefer Behavior = "defer"
+	// BehaviorNotice is BehaviorDefer that also tells the conversation, once, that the agent will catch up later.
+	BehaviorNotice Behavior = "notice"
+)
+
+// Policy is a token bucket (Burst invocations, refilling one every RefillEvery) plus a daily cap. A zero Burst or
+// DailyBudget turns that limit off.
+type Policy struct {
+	Burst       int
+	RefillEvery time.Duration
+	DailyBudget int
+	Behavior    Behavior
+}
+
+var DefaultPolicy = Policy{
+	Burst:       10,
+	RefillEvery: time.Minute,
+	DailyBudget: 200,
+	Behavior:    BehaviorNotice,
+}
+
+// ParsePolicy builds a policy from its string settings (e.g. environment variables); empty settings keep the
+// default. refillEvery is a duration like "30s".
+func ParsePolicy(burst, refillEvery, dailyBudget, behavior string) (Policy, error) {
+	policy := DefaultPolicy
+
+	if burst != "" {
+		n, err := strconv.Atoi(burst)
+		if err != nil || n < 0 {
+			return Policy{}, fmt.Errorf("invalid burst: %s", burst)
+		}
+		policy.Burst = n
+	}
+
+	if refillEvery != "" {
+		d, err := time.ParseDuration(refillEvery)
+		if err != nil || d <= 0 {
+			return Policy{}, fmt.Errorf("invalid refill interval: %s", refillEvery)
+		}
+		policy.RefillEvery = d
+	}
+
+	if dailyBudget != "" {
+		n, err := strconv.Atoi(dailyBudget)
+		if err != nil || n < 0 {
+			return Policy{}, fmt.Errorf("invalid daily budget: %s", dailyBudget)
+		}
+		policy.DailyBudget = n
+	}
+
+	if behavior != "" {
+		switch Behavior(behavior) {
+		case BehaviorDrop, BehaviorDefer, BehaviorNotice:
+			policy.Behavior = Behavior(behavior)
+		default:
+			return Policy{}, fmt.Errorf("invalid behavior: %s", behavior)
+		}
+	}
+
+	return policy, nil
+}
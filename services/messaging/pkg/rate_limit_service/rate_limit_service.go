@@ -0,0 +1,149 @@
+package rate_limit_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/rate_limit_repository"
+	"github.com/rs/zerolog"
+)
+
+// Other Lambda instances can be updating the same conversation; we re-read and retry this many times.
+const maxConflictRetries = 5
+
+const (
+	ReasonRateLimit   = "rate_limit"
+	ReasonDailyBudget = "daily_budget"
+)
+
+// Decision is whether the conversation can invoke the agent now, and what to do about it.
+type Decision struct {
+	Allowed bool
+	// Reason is why it isn't allowed.
+	Reason string
+	// CatchUpSince is set when earlier invocations were deferred; the agent should catch up on what happened since.
+	CatchUpSince time.Time
+	// SendNotice is set the first time the conversation is turned away under BehaviorNotice.
+	SendNotice bool
+	// RetryAt is when the conversation will be allowed again; it's only set when the invocation is deferred (rather
+	// than dropped), so the caller knows when to try it again.
+	RetryAt time.Time
+}
+
+// RateLimitService keeps a chatty conversation (or a spam burst) from running up the bill. Its state is in a
+// repository, so the limits hold across Lambda instances.
+type RateLimitService struct {
+	policy Policy
+	repo   rate_limit_repository.RateLimitRepository
+}
+
+func NewRateLimitService(policy Policy, repo rate_limit_repository.RateLimitRepository) *RateLimitService {
+	return &RateLimitService{
+		policy: policy,
+		repo:   repo,
+	}
+}
+
+// Allow decides whether the conversation can invoke the agent now; an allowed invocation is counted against the
+// limits.
+func (s *RateLimitService) Allow(ctx context.Context, conversationId string, now time.Time) (*Decision, error) {
+	logger := zerolog.Ctx(ctx)
+
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		limit, err := s.repo.GetLimit(conversationId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rate limit: %w", err)
+		}
+		if limit == nil {
+			limit = &rate_limit_repository.Limit{
+				ConversationId: conversationId,
+				Tokens:         float64(s.policy.Burst),
+				RefilledAt:     now.UnixMilli(),
+			}
+		}
+
+		decision := s.decide(limit, now)
+
+		err = s.repo.SaveLimit(limit)
+		if errors.Is(err, rate_limit_repository.ErrConflict) {
+			logger.Debug().Str("conversation_id", conversationId).Msg("rate limit conflict, retrying")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save rate limit: %w", err)
+		}
+
+		return decision, nil
+	}
+
+	return nil, fmt.Errorf("failed to update rate limit after %d attempts", maxConflictRetries)
+}
+
+// decide updates the limit for an invocation attempt at `now`.
+func (s *RateLimitService) decide(limit *rate_limit_repository.Limit, now time.Time) *Decision {
+	if s.policy.Burst > 0 {
+		elapsed := now.Sub(time.UnixMilli(limit.RefilledAt))
+		if elapsed > 0 {
+			limit.Tokens = math.Min(float64(s.policy.Burst), limit.Tokens+float64(elapsed)/float64(s.policy.RefillEvery))
+			limit.RefilledAt = now.UnixMilli()
+		}
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	if limit.Day != day {
+		limit.Day = day
+		limit.DayInvocations = 0
+	}
+
+	reason := ""
+	switch {
+	case s.policy.DailyBudget > 0 && limit.DayInvocations >= s.policy.DailyBudget:
+		reason = ReasonDailyBudget
+	case s.policy.Burst > 0 && limit.Tokens < 1:
+		reason = ReasonRateLimit
+	}
+
+	if reason != "" {
+		if limit.LimitedSince == 0 {
+			limit.LimitedSince = now.UnixMilli()
+		}
+		decision := &Decision{Reason: reason}
+		if s.policy.Behavior != BehaviorDrop {
+			decision.RetryAt = s.retryAt(limit, reason, now)
+		}
+		if s.policy.Behavior == BehaviorNotice && !limit.NoticeSent {
+			decision.SendNotice = true
+			limit.NoticeSent = true
+		}
+		return decision
+	}
+
+	if s.policy.Burst > 0 {
+		limit.Tokens--
+	}
+	limit.DayInvocations++
+
+	decision := &Decision{Allowed: true}
+	if limit.LimitedSince != 0 && s.policy.Behavior != BehaviorDrop {
+		decision.CatchUpSince = time.UnixMilli(limit.LimitedSince)
+	}
+	limit.LimitedSince = 0
+	limit.NoticeSent = false
+
+	return decision
+}
+
+// retryAt is when the limit that turned the conversation away will have let up: the next UTC day for the daily
+// budget, or when the bucket will have refilled a whole token.
+func (s *RateLimitService) retryAt(limit *rate_limit_repository.Limit, reason string, now time.Time) time.Time {
+	if reason == ReasonDailyBudget {
+		year, month, day := now.UTC().Date()
+		return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+	}
+
+	refill := time.Duration((1 - limit.Tokens) * float64(s.policy.RefillEvery))
+	return time.UnixMilli(limit.RefilledAt).Add(refill)
+}
@@ -0,0 +1,107 @@
+package rate_limit_service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/rate_limit_repository"
+)
+
+type fakeRateLimitRepository struct {
+	limits map[string]*rate_limit_repository.Limit
+}
+
+func (r *fakeRateLimitRepository) GetLimit(conversationId string) (*rate_limit_repository.Limit, error) {
+	limit, ok := r.limits[conversationId]
+	if !ok {
+		return nil, nil
+	}
+	copied := *limit
+	return &copied, nil
+}
+
+func (r *fakeRateLimitRepository) SaveLimit(limit *rate_limit_repository.Limit) error {
+	saved := *limit
+	saved.Version++
+	r.limits[limit.ConversationId] = &saved
+	return nil
+}
+
+func TestAllow(t *testing.T) {
+	start := time.Date(2025, 8, 22, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		attempts []time.Time
+		// want is the decision for the last attempt.
+		want Decision
+	}{
+		{
+			name:     "under the limit",
+			policy:   Policy{Burst: 2, RefillEvery: time.Minute, Behavior: BehaviorDefer},
+			attempts: []time.Time{start, start},
+			want:     Decision{Allowed: true},
+		},
+		{
+			name:     "drop has nothing to retry",
+			policy:   Policy{Burst: 1, RefillEvery: time.Minute, Behavior: BehaviorDrop},
+			attempts: []time.Time{start, start},
+			want:     Decision{Reason: ReasonRateLimit},
+		},
+		{
+			name:     "defer retries when a token refills",
+			policy:   Policy{Burst: 1, RefillEvery: time.Minute, Behavior: BehaviorDefer},
+			attempts: []time.Time{start, start.Add(15 * time.Second)},
+			want:     Decision{Reason: ReasonRateLimit, RetryAt: start.Add(time.Minute)},
+		},
+		{
+			name:     "notice retries and sends the notice",
+			policy:   Policy{Burst: 1, RefillEvery: time.Minute, Behavior: BehaviorNotice},
+			attempts: []time.Time{start, start},
+			want:     Decision{Reason: ReasonRateLimit, RetryAt: start.Add(time.Minute), SendNotice: true},
+		},
+		{
+			name:     "notice is only sent once",
+			policy:   Policy{Burst: 1, RefillEvery: time.Minute, Behavior: BehaviorNotice},
+			attempts: []time.Time{start, start, start.Add(30 * time.Second)},
+			want:     Decision{Reason: ReasonRateLimit, RetryAt: start.Add(time.Minute)},
+		},
+		{
+			name:     "deferred run catches up once it's allowed",
+			policy:   Policy{Burst: 1, RefillEvery: time.Minute, Behavior: BehaviorDefer},
+			attempts: []time.Time{start, start.Add(10 * time.Second), start.Add(time.Minute)},
+			want:     Decision{Allowed: true, CatchUpSince: start.Add(10 * time.Second)},
+		},
+		{
+			name:     "daily budget retries the next day",
+			policy:   Policy{DailyBudget: 1, Behavior: BehaviorDefer},
+			attempts: []time.Time{start, start.Add(time.Hour)},
+			want:     Decision{Reason: ReasonDailyBudget, RetryAt: time.Date(2025, 8, 23, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewRateLimitService(tt.policy, &fakeRateLimitRepository{limits: map[string]*rate_limit_repository.Limit{}})
+
+			var decision *Decision
+			for _, at := range tt.attempts {
+				var err error
+				decision, err = service.Allow(context.Background(), "a_b", at)
+				if err != nil {
+					t.Fatalf("Allow() error = %v", err)
+				}
+			}
+
+			if decision.Allowed != tt.want.Allowed ||
+				decision.Reason != tt.want.Reason ||
+				decision.SendNotice != tt.want.SendNotice ||
+				!decision.RetryAt.Equal(tt.want.RetryAt) ||
+				!decision.CatchUpSince.Equal(tt.want.CatchUpSince) {
+				t.Errorf("Allow() = %+v, want %+v", *decision, tt.want)
+			}
+		})
+	}
+}
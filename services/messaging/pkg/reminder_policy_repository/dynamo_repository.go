@@ -0,0 +1,74 @@
+package reminder_policy_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (ReminderPolicyRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-reminder-policies",
+	}, nil
+}
+
+func (r *DynamoRepository) GetPolicy(conversationId string) (*Policy, error) {
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var policy Policy
+	err = attributevalue.UnmarshalMap(result.Item, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (r *DynamoRepository) SavePolicy(policy *Policy) (*Policy, error) {
+	policy.UpdatedAt = time.Now().UnixMilli()
+
+	av, err := attributevalue.MarshalMap(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal policy: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return policy, nil
+}
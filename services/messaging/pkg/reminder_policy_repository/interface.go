@@ -0,0 +1,8 @@
+package reminder_policy_repository
+
+type ReminderPolicyRepository interface {
+	// GetPolicy returns nil if the conversation doesn't have its own policy.
+	GetPolicy(conversationId string) (*Policy, error)
+	// SavePolicy creates or replaces the conversation's policy.
+	SavePolicy(policy *Policy) (*Policy, error)
+}
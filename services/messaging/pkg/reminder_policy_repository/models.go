@@ -0,0 +1,12 @@
+package reminder_policy_repository
+
+// Policy is how a conversation wants to be reminded about tasks that are due.
+type Policy struct {
+	ConversationId     string `json:"conversation_id" dynamodbav:"conversation_id"`
+	LeadTimeMinutes    int    `json:"lead_time_minutes" dynamodbav:"lead_time_minutes"`
+	RepeatEveryMinutes int    `json:"repeat_every_minutes" dynamodbav:"repeat_every_minutes"`
+	// QuietHours is a span of local time like `21:00-08:00`; empty means there aren't any.
+	QuietHours string `json:"quiet_hours,omitempty" dynamodbav:"quiet_hours,omitempty"`
+	Paused     bool   `json:"paused" dynamodbav:"paused"`
+	UpdatedAt  int64  `json:"updated_at" dynamodbav:"updated_at"` // UNIX timestamp in milliseconds
+}
@@ -0,0 +1,14 @@
+package reminder_repository
+
+import "errors"
+
+// ErrConflict means the reminder changed since it was read; another run already recorded it.
+var ErrConflict = errors.New("reminder was updated concurrently")
+
+type ReminderRepository interface {
+	// GetReminder returns nil if no reminders were sent for the task.
+	GetReminder(taskId string) (*Reminder, error)
+	// SaveReminder saves the reminder if it's still at the version it was read at, then bumps the version. It returns
+	// ErrConflict otherwise.
+	SaveReminder(reminder *Reminder) error
+}
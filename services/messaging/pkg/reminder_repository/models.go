@@ -0,0 +1,14 @@
+package reminder_repository
+
+// Reminder is what reminders were sent for a task's due date.
+type Reminder struct {
+	TaskId         string `json:"task_id" dynamodbav:"task_id"`
+	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
+	// DueOn is the due date (YYYY-MM-DD) the reminders were for; they start over if the task's due date changes.
+	DueOn         string `json:"due_on" dynamodbav:"due_on"`
+	DueSoonSentAt int64  `json:"due_soon_sent_at" dynamodbav:"due_soon_sent_at"` // UNIX timestamp in milliseconds; 0 if not sent
+	OverdueSentAt int64  `json:"overdue_sent_at" dynamodbav:"overdue_sent_at"`   // UNIX timestamp in milliseconds of the latest one
+	OverdueSent   int    `json:"overdue_sent" dynamodbav:"overdue_sent"`
+	// Version guards against concurrent updates from overlapping runs.
+	Version int64 `json:"version" dynamodbav:"version"`
+}
@@ -0,0 +1,131 @@
+package reminder_service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxLeadTime is as far ahead of a due date as reminders can go out; it bounds how far ahead each run looks.
+const MaxLeadTime = 7 * 24 * time.Hour
+
+// MaxRepeatEvery is the longest an overdue task can wait to be brought up again; along with maxOverdueReminders it
+// bounds how far back each run looks.
+const MaxRepeatEvery = 7 * 24 * time.Hour
+
+// Policy is how a conversation is reminded about tasks that are due.
+type Policy struct {
+	// LeadTime is how long before the start of the due date the "due soon" reminder goes out.
+	LeadTime time.Duration
+	// RepeatEvery is how often an overdue task is brought up again, up to maxOverdueReminders times; zero brings it up
+	// once.
+	RepeatEvery time.Duration
+	// QuietHours are in the conversation's timezone; reminders wait until they're over.
+	QuietHours QuietHours
+	Paused     bool
+}
+
+var DefaultPolicy = Policy{
+	LeadTime:    24 * time.Hour,
+	RepeatEvery: 24 * time.Hour,
+	QuietHours:  QuietHours{Start: 21 * 60, End: 8 * 60},
+}
+
+// ParsePolicy builds a policy from its string settings (e.g. environment variables); empty settings keep the
+// default. leadTime and repeatEvery are durations like "24h", and quietHours is a span like "21:00-08:00" or "none".
+func ParsePolicy(leadTime, repeatEvery, quietHours string) (Policy, error) {
+	policy := DefaultPolicy
+
+	if leadTime != "" {
+		d, err := ParseLeadTime(leadTime)
+		if err != nil {
+			return Policy{}, err
+		}
+		policy.LeadTime = d
+	}
+
+	if repeatEvery != "" {
+		d, err := ParseRepeatEvery(repeatEvery)
+		if err != nil {
+			return Policy{}, err
+		}
+		policy.RepeatEvery = d
+	}
+
+	if quietHours != "" {
+		q, err := ParseQuietHours(quietHours)
+		if err != nil {
+			return Policy{}, err
+		}
+		policy.QuietHours = q
+	}
+
+	return policy, nil
+}
+
+func ParseLeadTime(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 || d > MaxLeadTime {
+		return 0, fmt.Errorf("invalid lead time: %s", value)
+	}
+	return d.Truncate(time.Minute), nil
+}
+
+func ParseRepeatEvery(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 || (d > 0 && d < time.Hour) || d > MaxRepeatEvery {
+		return 0, fmt.Errorf("invalid repeat interval, use 0 or between an hour and a week: %s", value)
+	}
+	return d.Truncate(time.Minute), nil
+}
+
+// QuietHours is a span of the day, in minutes after midnight. It wraps around midnight if it ends before it starts,
+// and there aren't any quiet hours if it starts when it ends.
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours parses a span like "21:00-08:00"; "none" means there aren't any.
+func ParseQuietHours(value string) (QuietHours, error) {
+	value = strings.TrimSpace(value)
+	if value == "none" {
+		return QuietHours{}, nil
+	}
+
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours, use a span like 21:00-08:00: %s", value)
+	}
+
+	startTime, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours, use a span like 21:00-08:00: %s", value)
+	}
+	endTime, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours, use a span like 21:00-08:00: %s", value)
+	}
+
+	return QuietHours{
+		Start: startTime.Hour()*60 + startTime.Minute(),
+		End:   endTime.Hour()*60 + endTime.Minute(),
+	}, nil
+}
+
+// String is the span like "21:00-08:00", or "none".
+func (q QuietHours) String() string {
+	if q.Start == q.End {
+		return "none"
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+// Contains is true if the time of day, in t's location, is within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
@@ -0,0 +1,65 @@
+package reminder_service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_policy_repository"
+)
+
+// PolicyService keeps each conversation's reminder policy; conversations that haven't set one get the default.
+type PolicyService struct {
+	defaultPolicy Policy
+	repo          reminder_policy_repository.ReminderPolicyRepository
+}
+
+func NewPolicyService(defaultPolicy Policy, repo reminder_policy_repository.ReminderPolicyRepository) *PolicyService {
+	return &PolicyService{
+		defaultPolicy: defaultPolicy,
+		repo:          repo,
+	}
+}
+
+func (s *PolicyService) GetPolicy(conversationId string) (Policy, error) {
+	stored, err := s.repo.GetPolicy(conversationId)
+	if err != nil {
+		return Policy{}, err
+	}
+	if stored == nil {
+		return s.defaultPolicy, nil
+	}
+
+	quietHours := QuietHours{}
+	if stored.QuietHours != "" {
+		quietHours, err = ParseQuietHours(stored.QuietHours)
+		if err != nil {
+			return Policy{}, fmt.Errorf("conversation %s has %w", conversationId, err)
+		}
+	}
+
+	return Policy{
+		LeadTime:    time.Duration(stored.LeadTimeMinutes) * time.Minute,
+		RepeatEvery: time.Duration(stored.RepeatEveryMinutes) * time.Minute,
+		QuietHours:  quietHours,
+		Paused:      stored.Paused,
+	}, nil
+}
+
+// SavePolicy replaces the conversation's policy as a whole; later changes to the default don't affect it.
+func (s *PolicyService) SavePolicy(conversationId string, policy Policy) (Policy, error) {
+	stored := &reminder_policy_repository.Policy{
+		ConversationId:     conversationId,
+		LeadTimeMinutes:    int(policy.LeadTime / time.Minute),
+		RepeatEveryMinutes: int(policy.RepeatEvery / time.Minute),
+		Paused:             policy.Paused,
+	}
+	if policy.QuietHours.Start != policy.QuietHours.End {
+		stored.QuietHours = policy.QuietHours.String()
+	}
+
+	if _, err := s.repo.SavePolicy(stored); err != nil {
+		return Policy{}, err
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,304 @@
+package reminder_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/delivery_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_repository"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/rs/zerolog"
+)
+
+// maxOverdueReminders is how many times an overdue task is brought up before we leave it be.
+const maxOverdueReminders = 3
+
+// overdueWindow is how long after its due date an overdue task can still be brought up; after that it's left be even
+// if it hasn't had all of its reminders (e.g. the conversation was paused), so runs don't look at it forever.
+const overdueWindow = maxOverdueReminders * MaxRepeatEvery
+
+type reminderKind string
+
+const (
+	reminderDueSoon reminderKind = "due_soon"
+	reminderOverdue reminderKind = "overdue"
+)
+
+// ReminderService texts conversations about their open tasks that are due soon or overdue.
+type ReminderService struct {
+	defaultLocation *time.Location
+	deliveryService *delivery_service.DeliveryService
+	participantRepo participant_repository.ParticipantRepository
+	policyService   *PolicyService
+	reminderRepo    reminder_repository.ReminderRepository
+	repo            message_repository.MessageRepository
+	taskRepo        task_repository.TaskRepository
+}
+
+// defaultLocation is the timezone of conversations where no one has set theirs.
+func NewReminderService(
+	defaultLocation *time.Location,
+	deliveryService *delivery_service.DeliveryService,
+	participantRepo participant_repository.ParticipantRepository,
+	policyService *PolicyService,
+	reminderRepo reminder_repository.ReminderRepository,
+	repo message_repository.MessageRepository,
+	taskRepo task_repository.TaskRepository,
+) *ReminderService {
+	return &ReminderService{
+		defaultLocation: defaultLocation,
+		deliveryService: deliveryService,
+		participantRepo: participantRepo,
+		policyService:   policyService,
+		reminderRepo:    reminderRepo,
+		repo:            repo,
+		taskRepo:        taskRepo,
+	}
+}
+
+// pendingReminder is a reminder about to be sent, along with the task's record of the ones that already were.
+type pendingReminder struct {
+	task     *task_repository.Task
+	reminder *reminder_repository.Reminder
+	kind     reminderKind
+}
+
+// Run sends the reminders that are due as of now, in one message per conversation. A conversation that fails doesn't
+// stop the others; their errors are returned together.
+func (s *ReminderService) Run(ctx context.Context, now time.Time) error {
+	logger := zerolog.Ctx(ctx)
+
+	// Due dates are in each conversation's timezone, some of which are a day ahead of UTC, so this looks further out
+	// (and back) than any policy does and lets each conversation narrow it down.
+	dueFrom := now.UTC().Add(-overdueWindow - 48*time.Hour).Format(time.DateOnly)
+	dueTo := now.UTC().Add(MaxLeadTime + 48*time.Hour).Format(time.DateOnly)
+	tasks, err := s.taskRepo.ListOpenTasksDueBetween(dueFrom, dueTo)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks that are due: %w", err)
+	}
+
+	tasksByConversation := map[string][]*task_repository.Task{}
+	for _, task := range tasks {
+		tasksByConversation[task.ConversationId] = append(tasksByConversation[task.ConversationId], task)
+	}
+	conversationIds := make([]string, 0, len(tasksByConversation))
+	for conversationId := range tasksByConversation {
+		conversationIds = append(conversationIds, conversationId)
+	}
+	sort.Strings(conversationIds)
+
+	logger.Info().Int("tasks", len(tasks)).Int("conversations", len(conversationIds)).Msg("checking tasks for reminders")
+
+	var errs []error
+	for _, conversationId := range conversationIds {
+		if err := s.remindConversation(ctx, conversationId, tasksByConversation[conversationId], now); err != nil {
+			logger.Error().Err(err).Str("conversation_id", conversationId).Msg("failed to send reminders")
+			errs = append(errs, fmt.Errorf("conversation %s: %w", conversationId, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ReminderService) remindConversation(ctx context.Context, conversationId string, tasks []*task_repository.Task, now time.Time) error {
+	logger := zerolog.Ctx(ctx)
+
+	policy, err := s.policyService.GetPolicy(conversationId)
+	if err != nil {
+		return err
+	}
+	if policy.Paused {
+		return nil
+	}
+
+	participants, err := s.participantRepo.ListParticipantsByConversation(conversationId)
+	if err != nil {
+		return err
+	}
+
+	local := now.In(participant_repository.ConversationLocation(participants, s.defaultLocation))
+	if policy.QuietHours.Contains(local) {
+		logger.Debug().Str("conversation_id", conversationId).Msg("quiet hours, holding reminders")
+		return nil
+	}
+
+	pending := []*pendingReminder{}
+	for _, task := range tasks {
+		reminder, err := s.reminderRepo.GetReminder(task.Id)
+		if err != nil {
+			return err
+		}
+		reminder = currentReminder(task, reminder)
+
+		kind, err := nextReminder(task, reminder, policy, local)
+		if err != nil {
+			logger.Warn().Err(err).Str("task_id", task.Id).Msg("skipping task")
+			continue
+		}
+		if kind != "" {
+			pending = append(pending, &pendingReminder{task: task, reminder: reminder, kind: kind})
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// The external ID is made from the reminders the message carries, so if a run fails after sending it, the next one
+	// won't send it again. One that failed before the message was delivered leaves it without deliveries, and the next
+	// run delivers it.
+	keys := make([]string, len(pending))
+	for i, p := range pending {
+		keys[i] = p.key()
+	}
+	message, duplicate, err := s.repo.CreateMessageWithExternalId(
+		conversationId,
+		"reminders:"+strings.Join(keys, ","),
+		message_repository.FromAssistant,
+		reminderBody(pending, participants, local),
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	if duplicate && len(message.Deliveries) > 0 {
+		logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("reminders already sent")
+	} else {
+		message, err = s.deliveryService.Deliver(ctx, message)
+		if err != nil {
+			return fmt.Errorf("failed to deliver message: %w", err)
+		}
+		logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Int("reminders", len(pending)).Msg("sent reminders")
+	}
+
+	for _, p := range pending {
+		p.record(now)
+		if err := s.reminderRepo.SaveReminder(p.reminder); err != nil {
+			if errors.Is(err, reminder_repository.ErrConflict) {
+				logger.Info().Str("task_id", p.task.Id).Msg("reminder already recorded by another run")
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// currentReminder is the record of the reminders sent for the task's current due date. Reminders start over when the
+// due date moves; the version carries on so the save can't clobber a concurrent one.
+func currentReminder(task *task_repository.Task, reminder *reminder_repository.Reminder) *reminder_repository.Reminder {
+	if reminder != nil && reminder.DueOn == task.DueOn {
+		return reminder
+	}
+
+	version := int64(0)
+	if reminder != nil {
+		version = reminder.Version
+	}
+	return &reminder_repository.Reminder{TaskId: task.Id, ConversationId: task.ConversationId, DueOn: task.DueOn, Version: version}
+}
+
+// nextReminder is the reminder the task is due for, or empty if it isn't due for one. local is now, in the
+// conversation's timezone.
+func nextReminder(task *task_repository.Task, reminder *reminder_repository.Reminder, policy Policy, local time.Time) (reminderKind, error) {
+	dueDay, err := time.ParseInLocation(time.DateOnly, task.DueOn, local.Location())
+	if err != nil {
+		return "", fmt.Errorf("invalid due date: %s", task.DueOn)
+	}
+
+	if !local.Before(dueDay.AddDate(0, 0, 1)) {
+		switch {
+		case reminder.OverdueSent >= maxOverdueReminders, !local.Before(dueDay.AddDate(0, 0, 1).Add(overdueWindow)):
+			return "", nil
+		case reminder.OverdueSent == 0:
+			return reminderOverdue, nil
+		case policy.RepeatEvery > 0 && local.Sub(time.UnixMilli(reminder.OverdueSentAt)) >= policy.RepeatEvery:
+			return reminderOverdue, nil
+		}
+		return "", nil
+	}
+
+	if reminder.DueSoonSentAt == 0 && !local.Before(dueDay.Add(-policy.LeadTime)) {
+		return reminderDueSoon, nil
+	}
+
+	return "", nil
+}
+
+// key identifies this reminder among all the ones sent for the task.
+func (p *pendingReminder) key() string {
+	key := p.task.Id + "/" + p.task.DueOn + "/" + string(p.kind)
+	if p.kind == reminderOverdue {
+		key += "/" + strconv.Itoa(p.reminder.OverdueSent+1)
+	}
+	return key
+}
+
+func (p *pendingReminder) record(now time.Time) {
+	switch p.kind {
+	case reminderDueSoon:
+		p.reminder.DueSoonSentAt = now.UnixMilli()
+	case reminderOverdue:
+		p.reminder.OverdueSentAt = now.UnixMilli()
+		p.reminder.OverdueSent++
+	}
+}
+
+func reminderBody(pending []*pendingReminder, participants []*participant_repository.Participant, local time.Time) string {
+	names := map[string]string{}
+	for _, participant := range participants {
+		if participant.DisplayName != "" {
+			names[participant.PhoneNumber] = participant.DisplayName
+		}
+	}
+
+	lines := make([]string, len(pending))
+	for i, p := range pending {
+		line := "\"" + p.task.Name + "\""
+		if p.task.Assignee != "" {
+			name, ok := names[p.task.Assignee]
+			if !ok {
+				name = p.task.Assignee
+			}
+			line += " (" + name + ")"
+		}
+
+		// nextReminder already checked that the due date parses.
+		dueDay, _ := time.ParseInLocation(time.DateOnly, p.task.DueOn, local.Location())
+		if p.kind == reminderDueSoon {
+			line += " is due " + describeDay(dueDay, local) + "."
+		} else {
+			line += " was due " + describeDay(dueDay, local) + "."
+		}
+		lines[i] = line
+	}
+
+	if len(lines) == 1 {
+		return "Reminder: " + lines[0]
+	}
+	return "Reminders:\n- " + strings.Join(lines, "\n- ")
+}
+
+// describeDay is the day relative to local's, like "tomorrow", or else like "Fri 8/22".
+func describeDay(day time.Time, local time.Time) string {
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	switch int(math.Round(day.Sub(today).Hours() / 24)) {
+	case -1:
+		return "yesterday"
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+	return day.Format("Mon 1/2")
+}
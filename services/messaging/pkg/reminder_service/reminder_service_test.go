@@ -0,0 +1,371 @@
+package reminder_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/delivery_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/opt_out_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/participant_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_policy_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/sms_sender"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+)
+
+func TestNextReminder(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &task_repository.Task{Id: "t1", ConversationId: "a_b", DueOn: "2025-08-23"}
+	// When the due date starts, in Denver.
+	dueStart := time.Date(2025, 8, 23, 0, 0, 0, 0, denver)
+	overdueStart := dueStart.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		reminder reminder_repository.Reminder
+		local    time.Time
+		want     reminderKind
+	}{
+		{
+			name:   "before the lead time",
+			policy: Policy{LeadTime: 24 * time.Hour},
+			local:  dueStart.Add(-25 * time.Hour),
+		},
+		{
+			name:   "within the lead time",
+			policy: Policy{LeadTime: 24 * time.Hour},
+			local:  dueStart.Add(-23 * time.Hour),
+			want:   reminderDueSoon,
+		},
+		{
+			name:   "no lead time waits for the due date",
+			policy: Policy{},
+			local:  dueStart,
+			want:   reminderDueSoon,
+		},
+		{
+			name:     "due soon was already sent",
+			policy:   Policy{LeadTime: 24 * time.Hour},
+			reminder: reminder_repository.Reminder{DueOn: task.DueOn, DueSoonSentAt: dueStart.Add(-23 * time.Hour).UnixMilli()},
+			local:    dueStart.Add(12 * time.Hour),
+		},
+		{
+			name:   "overdue the day after",
+			policy: Policy{LeadTime: 24 * time.Hour, RepeatEvery: 24 * time.Hour},
+			local:  overdueStart,
+			want:   reminderOverdue,
+		},
+		{
+			name:     "overdue isn't repeated before the interval",
+			policy:   Policy{RepeatEvery: 24 * time.Hour},
+			reminder: reminder_repository.Reminder{DueOn: task.DueOn, OverdueSent: 1, OverdueSentAt: overdueStart.UnixMilli()},
+			local:    overdueStart.Add(23 * time.Hour),
+		},
+		{
+			name:     "overdue is repeated after the interval",
+			policy:   Policy{RepeatEvery: 24 * time.Hour},
+			reminder: reminder_repository.Reminder{DueOn: task.DueOn, OverdueSent: 2, OverdueSentAt: overdueStart.Add(24 * time.Hour).UnixMilli()},
+			local:    overdueStart.Add(48 * time.Hour),
+			want:     reminderOverdue,
+		},
+		{
+			name:     "overdue stops at the cap",
+			policy:   Policy{RepeatEvery: 24 * time.Hour},
+			reminder: reminder_repository.Reminder{DueOn: task.DueOn, OverdueSent: maxOverdueReminders, OverdueSentAt: overdueStart.Add(48 * time.Hour).UnixMilli()},
+			local:    overdueStart.Add(96 * time.Hour),
+		},
+		{
+			name:     "overdue isn't repeated without an interval",
+			policy:   Policy{},
+			reminder: reminder_repository.Reminder{DueOn: task.DueOn, OverdueSent: 1, OverdueSentAt: overdueStart.UnixMilli()},
+			local:    overdueStart.Add(96 * time.Hour),
+		},
+		{
+			name:   "overdue stops after the window",
+			policy: Policy{RepeatEvery: 24 * time.Hour},
+			local:  overdueStart.Add(overdueWindow),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := tt.reminder
+			got, err := nextReminder(task, &reminder, tt.policy, tt.local)
+			if err != nil {
+				t.Fatalf("nextReminder() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("nextReminder() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentReminderDueDateMoved(t *testing.T) {
+	moved := &task_repository.Task{Id: "t1", ConversationId: "a_b", DueOn: "2025-08-30"}
+	sent := &reminder_repository.Reminder{TaskId: "t1", ConversationId: "a_b", DueOn: "2025-08-23", DueSoonSentAt: 1, OverdueSentAt: 2, OverdueSent: maxOverdueReminders, Version: 4}
+
+	reminder := currentReminder(moved, sent)
+	if reminder.DueOn != moved.DueOn || reminder.DueSoonSentAt != 0 || reminder.OverdueSent != 0 || reminder.Version != 4 {
+		t.Errorf("currentReminder() = %+v, want a fresh reminder for %s at version 4", reminder, moved.DueOn)
+	}
+
+	// With its reminders starting over, the moved task is reminded about again.
+	local := time.Date(2025, 8, 31, 12, 0, 0, 0, time.UTC)
+	if kind, err := nextReminder(moved, reminder, DefaultPolicy, local); err != nil || kind != reminderOverdue {
+		t.Errorf("nextReminder() = %q, %v, want %q", kind, err, reminderOverdue)
+	}
+
+	if same := currentReminder(&task_repository.Task{Id: "t1", DueOn: "2025-08-23"}, sent); same != sent {
+		t.Errorf("currentReminder() = %+v, want the reminder already sent", same)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 8, 22, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		value    string
+		at       time.Time
+		want     bool
+		wantSpan string
+	}{
+		{value: "21:00-08:00", at: at(22, 0), want: true, wantSpan: "21:00-08:00"},
+		{value: "21:00-08:00", at: at(3, 0), want: true, wantSpan: "21:00-08:00"},
+		{value: "21:00-08:00", at: at(8, 0), want: false, wantSpan: "21:00-08:00"},
+		{value: "21:00-08:00", at: at(20, 59), want: false, wantSpan: "21:00-08:00"},
+		{value: "12:30-13:30", at: at(12, 30), want: true, wantSpan: "12:30-13:30"},
+		{value: "12:30-13:30", at: at(13, 30), want: false, wantSpan: "12:30-13:30"},
+		{value: "none", at: at(3, 0), want: false, wantSpan: "none"},
+		{value: "09:00-09:00", at: at(9, 0), want: false, wantSpan: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" at "+tt.at.Format("15:04"), func(t *testing.T) {
+			quietHours, err := ParseQuietHours(tt.value)
+			if err != nil {
+				t.Fatalf("ParseQuietHours() error = %v", err)
+			}
+			if got := quietHours.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+			if got := quietHours.String(); got != tt.wantSpan {
+				t.Errorf("String() = %q, want %q", got, tt.wantSpan)
+			}
+		})
+	}
+
+	// Quiet hours are in the conversation's timezone.
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !DefaultPolicy.QuietHours.Contains(at(4, 0).In(denver)) {
+		t.Errorf("Contains() = false at 22:00 in Denver, want true")
+	}
+
+	for _, value := range []string{"21:00", "9pm-8am", "25:00-08:00"} {
+		if _, err := ParseQuietHours(value); err == nil {
+			t.Errorf("ParseQuietHours(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseRepeatEvery(t *testing.T) {
+	for _, value := range []string{"0", "1h", "24h", "168h"} {
+		if _, err := ParseRepeatEvery(value); err != nil {
+			t.Errorf("ParseRepeatEvery(%q) error = %v", value, err)
+		}
+	}
+	for _, value := range []string{"30m", "169h", "-1h", "daily"} {
+		if _, err := ParseRepeatEvery(value); err == nil {
+			t.Errorf("ParseRepeatEvery(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+// The fakes embed their interfaces so only the methods a run uses need implementing.
+
+type fakeMessageRepository struct {
+	message_repository.MessageRepository
+	messages map[string]*message_repository.Message // by external ID
+	byId     map[string]*message_repository.Message
+}
+
+func (r *fakeMessageRepository) CreateMessageWithExternalId(conversationId, externalId, from, body string, attachments []*message_repository.Attachment) (*message_repository.Message, bool, error) {
+	if message, ok := r.messages[externalId]; ok {
+		copied := *message
+		return &copied, true, nil
+	}
+	message := &message_repository.Message{Id: externalId, ConversationId: conversationId, From: from, Body: body}
+	r.messages[externalId] = message
+	r.byId[message.Id] = message
+	copied := *message
+	return &copied, false, nil
+}
+
+func (r *fakeMessageRepository) SetDeliveries(id string, deliveries map[string]*message_repository.Delivery) (*message_repository.Message, error) {
+	message := r.byId[id]
+	message.Deliveries = deliveries
+	copied := *message
+	return &copied, nil
+}
+
+// fakeOptOutRepository fails while err is set, which fails delivery before anything is sent.
+type fakeOptOutRepository struct {
+	opt_out_repository.OptOutRepository
+	err error
+}
+
+func (r *fakeOptOutRepository) GetOptOuts(phoneNumbers []string) (map[string]*opt_out_repository.OptOut, error) {
+	return map[string]*opt_out_repository.OptOut{}, r.err
+}
+
+type fakeParticipantRepository struct {
+	participant_repository.ParticipantRepository
+}
+
+func (r *fakeParticipantRepository) ListParticipantsByConversation(conversationId string) ([]*participant_repository.Participant, error) {
+	return []*participant_repository.Participant{}, nil
+}
+
+type fakePolicyRepository struct {
+	reminder_policy_repository.ReminderPolicyRepository
+}
+
+func (r *fakePolicyRepository) GetPolicy(conversationId string) (*reminder_policy_repository.Policy, error) {
+	return nil, nil
+}
+
+type fakeReminderRepository struct {
+	reminder_repository.ReminderRepository
+	reminders map[string]*reminder_repository.Reminder
+}
+
+func (r *fakeReminderRepository) GetReminder(taskId string) (*reminder_repository.Reminder, error) {
+	reminder, ok := r.reminders[taskId]
+	if !ok {
+		return nil, nil
+	}
+	copied := *reminder
+	return &copied, nil
+}
+
+func (r *fakeReminderRepository) SaveReminder(reminder *reminder_repository.Reminder) error {
+	if stored, ok := r.reminders[reminder.TaskId]; ok && stored.Version != reminder.Version {
+		return reminder_repository.ErrConflict
+	}
+	copied := *reminder
+	copied.Version++
+	r.reminders[reminder.TaskId] = &copied
+	return nil
+}
+
+type fakeTaskRepository struct {
+	task_repository.TaskRepository
+	tasks []*task_repository.Task
+}
+
+func (r *fakeTaskRepository) ListOpenTasksDueBetween(dueFrom, dueTo string) ([]*task_repository.Task, error) {
+	return r.tasks, nil
+}
+
+func TestRunRetriesUndeliveredReminders(t *testing.T) {
+	ctx := context.Background()
+	const conversationId = "+15555550100_+15555550101_+15555550102"
+	// Midday on the due date, in UTC; the default timezone.
+	now := time.Date(2025, 8, 23, 12, 0, 0, 0, time.UTC)
+
+	optOutRepo := &fakeOptOutRepository{err: errors.New("throttled")}
+	repo := &fakeMessageRepository{messages: map[string]*message_repository.Message{}, byId: map[string]*message_repository.Message{}}
+	smsSender := sms_sender.NewMemory()
+	reminderRepo := &fakeReminderRepository{reminders: map[string]*reminder_repository.Reminder{}}
+	service := NewReminderService(
+		time.UTC,
+		delivery_service.NewDeliveryService(compliance_service.NewComplianceService(optOutRepo, compliance_service.DefaultHelpMessage), repo, smsSender, "+15555550100", ""),
+		&fakeParticipantRepository{},
+		NewPolicyService(DefaultPolicy, &fakePolicyRepository{}),
+		reminderRepo,
+		repo,
+		&fakeTaskRepository{tasks: []*task_repository.Task{
+			{Id: "t1", ConversationId: conversationId, Name: "Buy ice", Status: task_repository.TaskStatusOpen, DueOn: "2025-08-23"},
+		}},
+	)
+
+	// The message is created, but delivering it fails, so the reminder isn't recorded.
+	if err := service.Run(ctx, now); err == nil {
+		t.Fatalf("Run() succeeded, want the delivery error")
+	}
+	if len(repo.messages) != 1 || len(smsSender.Sent()) != 0 || len(reminderRepo.reminders) != 0 {
+		t.Fatalf("after the failed run: %d messages, %d texts, %d reminders, want 1, 0, 0", len(repo.messages), len(smsSender.Sent()), len(reminderRepo.reminders))
+	}
+
+	// The next run finds the message it created and delivers it.
+	optOutRepo.err = nil
+	if err := service.Run(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(repo.messages) != 1 || len(smsSender.Sent()) != 2 {
+		t.Errorf("after the retry: %d messages, %d texts, want 1, 2", len(repo.messages), len(smsSender.Sent()))
+	}
+	if reminder := reminderRepo.reminders["t1"]; reminder == nil || reminder.DueSoonSentAt == 0 {
+		t.Errorf("reminder = %+v, want due soon recorded", reminder)
+	}
+
+	// Once it's recorded, it isn't sent again.
+	if err := service.Run(ctx, now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(smsSender.Sent()) != 2 {
+		t.Errorf("texts = %d, want 2", len(smsSender.Sent()))
+	}
+}
+
+func TestRunDoesNotResendDeliveredReminders(t *testing.T) {
+	ctx := context.Background()
+	const conversationId = "+15555550100_+15555550101"
+	now := time.Date(2025, 8, 23, 12, 0, 0, 0, time.UTC)
+	task := &task_repository.Task{Id: "t1", ConversationId: conversationId, Name: "Buy ice", Status: task_repository.TaskStatusOpen, DueOn: "2025-08-23"}
+
+	// A run sent the message but failed before recording the reminder.
+	repo := &fakeMessageRepository{messages: map[string]*message_repository.Message{}, byId: map[string]*message_repository.Message{}}
+	externalId := "reminders:" + (&pendingReminder{task: task, kind: reminderDueSoon}).key()
+	sent := &message_repository.Message{Id: "m1", ConversationId: conversationId, Deliveries: map[string]*message_repository.Delivery{
+		"+15555550101": {To: "+15555550101", Status: message_repository.DeliveryStatusQueued},
+	}}
+	repo.messages[externalId] = sent
+	repo.byId[sent.Id] = sent
+
+	smsSender := sms_sender.NewMemory()
+	reminderRepo := &fakeReminderRepository{reminders: map[string]*reminder_repository.Reminder{}}
+	service := NewReminderService(
+		time.UTC,
+		delivery_service.NewDeliveryService(compliance_service.NewComplianceService(&fakeOptOutRepository{}, compliance_service.DefaultHelpMessage), repo, smsSender, "+15555550100", ""),
+		&fakeParticipantRepository{},
+		NewPolicyService(DefaultPolicy, &fakePolicyRepository{}),
+		reminderRepo,
+		repo,
+		&fakeTaskRepository{tasks: []*task_repository.Task{task}},
+	)
+
+	if err := service.Run(ctx, now); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(smsSender.Sent()) != 0 {
+		t.Errorf("texts = %d, want 0", len(smsSender.Sent()))
+	}
+	if reminder := reminderRepo.reminders["t1"]; reminder == nil || reminder.DueSoonSentAt == 0 {
+		t.Errorf("reminder = %+v, want due soon recorded", reminder)
+	}
+}
@@ -0,0 +1,34 @@
+package search_index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words that are too common to be useful in a query.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true, "by": true,
+	"for": true, "if": true, "in": true, "into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "so": true, "such": true, "that": true, "the": true, "their": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// tokenize splits text into lowercase terms on anything that isn't a letter or a number, drops stop words, and strips
+// possessives ("joe's" -> "joe").
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.Trim(field, "'’")
+		field = strings.TrimSuffix(field, "'s")
+		field = strings.TrimSuffix(field, "’s")
+		if field == "" || stopWords[field] {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
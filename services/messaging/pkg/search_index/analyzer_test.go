@@ -0,0 +1,29 @@
+package search_index
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Pick up the TENTS", want: []string{"pick", "up", "tents"}},
+		{text: "Joe's car", want: []string{"joe", "car"}},
+		{text: "Joe’s car", want: []string{"joe", "car"}},
+		{text: "'quoted' words", want: []string{"quoted", "words"}},
+		{text: "at 5:30, by the lake!", want: []string{"5", "30", "lake"}},
+		{text: "it is a the", want: []string{}},
+		{text: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package search_index
+
+import (
+	"context"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+)
+
+type SearchIndex interface {
+	// Index adds messages to the index; indexing a message again replaces it.
+	Index(ctx context.Context, messages ...*message_repository.Message) error
+	// Search returns the best matches for the query within a single conversation, best first.
+	Search(ctx context.Context, conversationId, query string, limit int) ([]*SearchResult, error)
+	// IndexedThrough returns the newest `sent_at` that has been indexed for the conversation, or 0 if none has.
+	IndexedThrough(ctx context.Context, conversationId string) (int64, error)
+}
+
+type SearchResult struct {
+	MessageId string  `json:"message_id"`
+	From      string  `json:"from"`
+	SentAt    int64   `json:"sent_at"` // UNIX timestamp in milliseconds
+	Body      string  `json:"body"`
+	Score     float64 `json:"score"`
+}
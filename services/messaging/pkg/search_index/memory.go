@@ -0,0 +1,184 @@
+package search_index
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+)
+
+// BM25 tuning, see https://en.wikipedia.org/wiki/Okapi_BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Memory is an in-process inverted index ranked with BM25. It only lives as long as the process, so callers should
+// Sync it from the repository before searching.
+type Memory struct {
+	mu            sync.RWMutex
+	conversations map[string]*conversationIndex
+}
+
+type conversationIndex struct {
+	documents      map[string]*document
+	postings       map[string]map[string]bool // term -> message IDs
+	totalLength    int
+	indexedThrough int64
+}
+
+type document struct {
+	message   *message_repository.Message
+	termFreqs map[string]int
+	length    int
+}
+
+func NewMemory() SearchIndex {
+	return &Memory{conversations: map[string]*conversationIndex{}}
+}
+
+func (m *Memory) Index(ctx context.Context, messages ...*message_repository.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, message := range messages {
+		index, ok := m.conversations[message.ConversationId]
+		if !ok {
+			index = &conversationIndex{
+				documents: map[string]*document{},
+				postings:  map[string]map[string]bool{},
+			}
+			m.conversations[message.ConversationId] = index
+		}
+		index.remove(message.Id)
+		index.add(message)
+	}
+
+	return nil
+}
+
+func (m *Memory) Search(ctx context.Context, conversationId, query string, limit int) ([]*SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []*SearchResult{}
+	index, ok := m.conversations[conversationId]
+	if !ok || len(index.documents) == 0 {
+		return results, nil
+	}
+
+	documentCount := float64(len(index.documents))
+	averageLength := float64(index.totalLength) / documentCount
+
+	scores := map[string]float64{}
+	for _, term := range uniqueTerms(tokenize(query)) {
+		ids := index.postings[term]
+		if len(ids) == 0 {
+			continue
+		}
+
+		documentFreq := float64(len(ids))
+		idf := math.Log(1 + (documentCount-documentFreq+0.5)/(documentFreq+0.5))
+		for id := range ids {
+			doc := index.documents[id]
+			termFreq := float64(doc.termFreqs[term])
+			norm := termFreq + bm25K1*(1-bm25B+bm25B*float64(doc.length)/averageLength)
+			scores[id] += idf * termFreq * (bm25K1 + 1) / norm
+		}
+	}
+
+	for id, score := range scores {
+		message := index.documents[id].message
+		results = append(results, &SearchResult{
+			MessageId: message.Id,
+			From:      message.From,
+			SentAt:    message.SentAt,
+			Body:      message.Body,
+			Score:     score,
+		})
+	}
+
+	// Best first; newer messages win ties.
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].SentAt > results[j].SentAt
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func (m *Memory) IndexedThrough(ctx context.Context, conversationId string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index, ok := m.conversations[conversationId]
+	if !ok {
+		return 0, nil
+	}
+	return index.indexedThrough, nil
+}
+
+func (c *conversationIndex) add(message *message_repository.Message) {
+	text := message.Body
+	for _, attachment := range message.Attachments {
+		text += " " + attachment.Description
+	}
+
+	terms := tokenize(text)
+	doc := &document{
+		message:   message,
+		termFreqs: map[string]int{},
+		length:    len(terms),
+	}
+	for _, term := range terms {
+		doc.termFreqs[term]++
+	}
+	for term := range doc.termFreqs {
+		if c.postings[term] == nil {
+			c.postings[term] = map[string]bool{}
+		}
+		c.postings[term][message.Id] = true
+	}
+
+	c.documents[message.Id] = doc
+	c.totalLength += doc.length
+	if message.SentAt > c.indexedThrough {
+		c.indexedThrough = message.SentAt
+	}
+}
+
+func (c *conversationIndex) remove(id string) {
+	doc, ok := c.documents[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.termFreqs {
+		delete(c.postings[term], id)
+		if len(c.postings[term]) == 0 {
+			delete(c.postings, term)
+		}
+	}
+	c.totalLength -= doc.length
+	delete(c.documents, id)
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,98 @@
+package search_index
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+)
+
+const conversationId = "+15555550100_+15555550101"
+
+func resultIds(results []*SearchResult) []string {
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.MessageId)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemory()
+	err := index.Index(ctx,
+		&message_repository.Message{Id: "m1", ConversationId: conversationId, SentAt: 1, Body: "pizza tonight"},
+		&message_repository.Message{Id: "m2", ConversationId: conversationId, SentAt: 2, Body: "pizza tomorrow"},
+		&message_repository.Message{Id: "m3", ConversationId: conversationId, SentAt: 3, Body: "pizza and a movie"},
+		&message_repository.Message{Id: "m4", ConversationId: conversationId, SentAt: 4, Body: "pizza tonight"},
+		&message_repository.Message{Id: "m5", ConversationId: "+15555550100_+15555550199", SentAt: 5, Body: "movie night"},
+	)
+	if err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		// "movie" is rarer than "pizza", so the message with both ranks first.
+		{query: "pizza movie", want: []string{"m3", "m4", "m2", "m1"}},
+		// m1 and m4 tie; the newer one wins.
+		{query: "tonight", want: []string{"m4", "m1"}},
+		{query: "pizza movie", limit: 2, want: []string{"m3", "m4"}},
+		{query: "the", want: []string{}},
+		{query: "night", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := index.Search(ctx, conversationId, tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if got := resultIds(results); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexAgain(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemory()
+
+	messages := []*message_repository.Message{
+		{Id: "m1", ConversationId: conversationId, SentAt: 1, Body: "bring the tent"},
+		{Id: "m2", ConversationId: conversationId, SentAt: 2, Body: "bring snacks"},
+		{Id: "m1", ConversationId: conversationId, SentAt: 1, Body: "bring the big blue tent",
+			Attachments: []*message_repository.Attachment{{Description: "a photo of a tent"}}},
+	}
+	for _, message := range messages {
+		if err := index.Index(ctx, message); err != nil {
+			t.Fatalf("Index() error = %v", err)
+		}
+	}
+
+	conversation := index.(*Memory).conversations[conversationId]
+	if len(conversation.documents) != 2 {
+		t.Errorf("documents = %d, want 2", len(conversation.documents))
+	}
+	// "bring big blue tent photo tent" and "bring snacks".
+	if conversation.totalLength != 8 {
+		t.Errorf("totalLength = %d, want 8", conversation.totalLength)
+	}
+
+	results, err := index.Search(ctx, conversationId, "blue photo", 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if got := resultIds(results); !slices.Equal(got, []string{"m1"}) {
+		t.Errorf("Search() = %q, want the re-indexed message", got)
+	}
+
+	if indexedThrough, err := index.IndexedThrough(ctx, conversationId); err != nil || indexedThrough != 2 {
+		t.Errorf("IndexedThrough() = %d, %v, want 2", indexedThrough, err)
+	}
+}
@@ -0,0 +1,37 @@
+package search_index
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+)
+
+// Sync indexes any messages in the conversation that are newer than what the index has seen. Messages sent in the
+// same millisecond as the newest indexed message are fetched again, which is fine because indexing is idempotent.
+func Sync(ctx context.Context, index SearchIndex, repo message_repository.MessageRepository, conversationId string) error {
+	indexedThrough, err := index.IndexedThrough(ctx, conversationId)
+	if err != nil {
+		return fmt.Errorf("failed to get indexed through: %w", err)
+	}
+
+	opts := message_repository.ListMessagesOptions{
+		After:    indexedThrough,
+		PageSize: message_repository.MaxPageSize,
+	}
+	for {
+		page, err := repo.ListMessages(ctx, conversationId, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
+
+		if err := index.Index(ctx, page.Messages...); err != nil {
+			return fmt.Errorf("failed to index messages: %w", err)
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,73 @@
+package search_index
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+)
+
+// fakeMessageRepository pages through its messages two at a time, using the index of the next message as the cursor.
+type fakeMessageRepository struct {
+	message_repository.MessageRepository
+	messages []*message_repository.Message
+	calls    []message_repository.ListMessagesOptions
+}
+
+func (r *fakeMessageRepository) ListMessages(ctx context.Context, conversationID string, opts message_repository.ListMessagesOptions) (*message_repository.MessagePage, error) {
+	r.calls = append(r.calls, opts)
+
+	matching := []*message_repository.Message{}
+	for _, message := range r.messages {
+		if message.ConversationId == conversationID && message.SentAt >= opts.After {
+			matching = append(matching, message)
+		}
+	}
+
+	start := 0
+	if opts.Cursor != "" {
+		start, _ = strconv.Atoi(opts.Cursor)
+	}
+	end := min(start+2, len(matching))
+
+	page := &message_repository.MessagePage{Messages: matching[start:end]}
+	if end < len(matching) {
+		page.NextCursor = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemory()
+	repo := &fakeMessageRepository{}
+	for i, body := range []string{"tents", "stove", "lantern", "cooler", "chairs"} {
+		repo.messages = append(repo.messages, &message_repository.Message{Id: "m" + strconv.Itoa(i+1), ConversationId: conversationId, SentAt: int64(i + 1), Body: body})
+	}
+
+	if err := Sync(ctx, index, repo, conversationId); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(repo.calls) != 3 {
+		t.Errorf("pages listed = %d, want 3", len(repo.calls))
+	}
+	for _, body := range []string{"tents", "chairs"} {
+		if results, err := index.Search(ctx, conversationId, body, 0); err != nil || len(results) != 1 {
+			t.Errorf("Search(%q) = %d results, %v, want 1", body, len(results), err)
+		}
+	}
+
+	// A later sync only lists what's new since the newest indexed message.
+	repo.calls = nil
+	repo.messages = append(repo.messages, &message_repository.Message{Id: "m6", ConversationId: conversationId, SentAt: 6, Body: "firewood"})
+	if err := Sync(ctx, index, repo, conversationId); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(repo.calls) != 1 || repo.calls[0].After != 5 {
+		t.Errorf("listed %+v, want one page after 5", repo.calls)
+	}
+	if results, err := index.Search(ctx, conversationId, "firewood", 0); err != nil || len(results) != 1 {
+		t.Errorf("Search(%q) = %d results, %v, want 1", "firewood", len(results), err)
+	}
+}
@@ -0,0 +1,14 @@
+package session_repository
+
+import "errors"
+
+// ErrConflict means the session changed since it was read; read it again and retry.
+var ErrConflict = errors.New("session was updated concurrently")
+
+type SessionRepository interface {
+	// GetSession returns nil if the conversation doesn't have a session yet.
+	GetSession(conversationId string) (*Session, error)
+	// SaveSession saves the session if it's still at the version it was read at, then bumps the version. It returns
+	// ErrConflict otherwise.
+	SaveSession(session *Session) error
+}
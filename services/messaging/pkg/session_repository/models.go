@@ -0,0 +1,12 @@
+package session_repository
+
+// Session is the Bedrock agent session a conversation is currently using.
+type Session struct {
+	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
+	SessionId      string `json:"session_id" dynamodbav:"session_id"`
+	Invocations    int    `json:"invocations" dynamodbav:"invocations"`
+	CreatedAt      int64  `json:"created_at" dynamodbav:"created_at"`     // UNIX timestamp in milliseconds
+	LastUsedAt     int64  `json:"last_used_at" dynamodbav:"last_used_at"` // UNIX timestamp in milliseconds
+	// Version guards against concurrent updates from other Lambda instances.
+	Version int64 `json:"version" dynamodbav:"version"`
+}
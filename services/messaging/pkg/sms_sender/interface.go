@@ -0,0 +1,9 @@
+package sms_sender
+
+import "context"
+
+type SmsSender interface {
+	// SendSms sends a text to a single E164 phone number and returns the provider's message ID (e.g. a Twilio SID).
+	// If statusCallbackUrl isn't empty, the provider will report delivery status changes to it.
+	SendSms(ctx context.Context, to, body, statusCallbackUrl string) (string, error)
+}
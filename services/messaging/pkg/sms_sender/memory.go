@@ -0,0 +1,46 @@
+package sms_sender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type SentSms struct {
+	Sid               string
+	To                string
+	Body              string
+	StatusCallbackUrl string
+}
+
+// Memory records texts instead of sending them; it's meant for tests and local runs.
+type Memory struct {
+	mu   sync.Mutex
+	sent []SentSms
+	// Numbers in Fail will return an error instead of being sent.
+	Fail map[string]error
+}
+
+func NewMemory() *Memory {
+	return &Memory{Fail: map[string]error{}}
+}
+
+func (m *Memory) SendSms(ctx context.Context, to, body, statusCallbackUrl string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err, ok := m.Fail[to]; ok {
+		return "", err
+	}
+
+	sid := fmt.Sprintf("SM%032d", len(m.sent)+1)
+	m.sent = append(m.sent, SentSms{Sid: sid, To: to, Body: body, StatusCallbackUrl: statusCallbackUrl})
+	return sid, nil
+}
+
+func (m *Memory) Sent() []SentSms {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SentSms{}, m.sent...)
+}
@@ -0,0 +1,78 @@
+package sms_sender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioBaseUrl = "https://api.twilio.com/2010-04-01"
+
+type Twilio struct {
+	accountSid string
+	authToken  string
+	fromNumber string
+	httpClient *http.Client
+}
+
+func NewTwilio(accountSid, authToken, fromNumber string) SmsSender {
+	return &Twilio{
+		accountSid: accountSid,
+		authToken:  authToken,
+		fromNumber: fromNumber,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// https://www.twilio.com/docs/messaging/api/message-resource#create-a-message-resource
+type twilioMessageResponse struct {
+	Sid     string `json:"sid"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (t *Twilio) SendSms(ctx context.Context, to, body, statusCallbackUrl string) (string, error) {
+	form := url.Values{}
+	form.Set("From", t.fromNumber)
+	form.Set("To", to)
+	form.Set("Body", body)
+	if statusCallbackUrl != "" {
+		form.Set("StatusCallback", statusCallbackUrl)
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioBaseUrl, t.accountSid)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	request.SetBasicAuth(t.accountSid, t.authToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := t.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request to Twilio: %w", err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Twilio response: %w", err)
+	}
+
+	var message twilioMessageResponse
+	if err := json.Unmarshal(responseBody, &message); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Twilio response (status %d): %w", response.StatusCode, err)
+	}
+
+	if response.StatusCode >= 300 {
+		return "", fmt.Errorf("twilio returned status %d: %d %s", response.StatusCode, message.Code, message.Message)
+	}
+
+	return message.Sid, nil
+}
@@ -0,0 +1,232 @@
+package trace_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// DynamoDB doesn't allow more than this many items in a batch write.
+const maxBatchWriteItems = 25
+
+// Unprocessed items are retried this many times, waiting twice as long before each retry.
+const (
+	maxBatchWriteRetries     = 5
+	initialBatchWriteBackoff = 100 * time.Millisecond
+)
+
+type DynamoRepository struct {
+	db                  *dynamodb.Client
+	invocationTableName string
+	eventTableName      string
+}
+
+func New(ctx context.Context) (TraceRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:                  db,
+		invocationTableName: "text-agent-agent-invocations",
+		eventTableName:      "text-agent-agent-trace-events",
+	}, nil
+}
+
+func (r *DynamoRepository) CreateInvocation(conversationId, sessionId, input string) (*Invocation, error) {
+	invocation := &Invocation{
+		Id:             uuid.NewString(),
+		ConversationId: conversationId,
+		SessionId:      sessionId,
+		Input:          input,
+		StartedAt:      time.Now().UnixMilli(),
+	}
+
+	av, err := attributevalue.MarshalMap(invocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal invocation: %w", err)
+	}
+
+	_, err = r.db.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String(r.invocationTableName),
+		Item:      av,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to put item in DynamoDB: %w", err)
+	}
+
+	return invocation, nil
+}
+
+func (r *DynamoRepository) FinishInvocation(id, finalText, errorMessage string) (*Invocation, error) {
+	update := "SET final_text = :finalText, finished_at = :finishedAt"
+	values := map[string]types.AttributeValue{
+		":finalText":  &types.AttributeValueMemberS{Value: finalText},
+		":finishedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().UnixMilli())},
+	}
+	if errorMessage != "" {
+		update += ", #error = :error"
+		values[":error"] = &types.AttributeValueMemberS{Value: errorMessage}
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.invocationTableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(id)"),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+	if errorMessage != "" {
+		input.ExpressionAttributeNames = map[string]string{"#error": "error"}
+	}
+
+	result, err := r.db.UpdateItem(context.Background(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	var invocation Invocation
+	err = attributevalue.UnmarshalMap(result.Attributes, &invocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invocation: %w", err)
+	}
+
+	return &invocation, nil
+}
+
+func (r *DynamoRepository) AddEvents(events []*TraceEvent) error {
+	for start := 0; start < len(events); start += maxBatchWriteItems {
+		end := min(start+maxBatchWriteItems, len(events))
+
+		requests := []types.WriteRequest{}
+		for _, event := range events[start:end] {
+			truncateEvent(event)
+			av, err := attributevalue.MarshalMap(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal trace event: %w", err)
+			}
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: av}})
+		}
+
+		if err := r.batchWrite(requests); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchWrite retries the items DynamoDB didn't process (usually because the table is being throttled), backing off
+// between attempts.
+func (r *DynamoRepository) batchWrite(requests []types.WriteRequest) error {
+	unprocessed := map[string][]types.WriteRequest{r.eventTableName: requests}
+	backoff := initialBatchWriteBackoff
+	for attempt := 0; ; attempt++ {
+		result, err := r.db.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
+			RequestItems: unprocessed,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to batch write items to DynamoDB: %w", err)
+		}
+
+		unprocessed = result.UnprocessedItems
+		if len(unprocessed) == 0 {
+			return nil
+		}
+		if attempt == maxBatchWriteRetries {
+			return fmt.Errorf("failed to write %d trace events after %d retries", len(unprocessed[r.eventTableName]), maxBatchWriteRetries)
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (r *DynamoRepository) ListInvocationsByConversation(conversationId string, limit int) ([]*Invocation, error) {
+	result, err := r.db.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:              aws.String(r.invocationTableName),
+		IndexName:              aws.String("ConversationIdIndex"),
+		KeyConditionExpression: aws.String("conversation_id = :convId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":convId": &types.AttributeValueMemberS{Value: conversationId},
+		},
+		ScanIndexForward: aws.Bool(false), // Newest first.
+		Limit:            aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+	}
+
+	if len(result.Items) == 0 {
+		return []*Invocation{}, nil
+	}
+
+	var invocations []*Invocation
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &invocations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invocations: %w", err)
+	}
+
+	return invocations, nil
+}
+
+func (r *DynamoRepository) GetTimeline(invocationId string) (*Timeline, error) {
+	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
+		TableName: aws.String(r.invocationTableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: invocationId},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item from DynamoDB: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var invocation Invocation
+	err = attributevalue.UnmarshalMap(result.Item, &invocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invocation: %w", err)
+	}
+
+	events := []*TraceEvent{}
+	paginator := dynamodb.NewQueryPaginator(r.db, &dynamodb.QueryInput{
+		TableName:              aws.String(r.eventTableName),
+		KeyConditionExpression: aws.String("invocation_id = :invocationId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":invocationId": &types.AttributeValueMemberS{Value: invocationId},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+		}
+
+		var pageEvents []*TraceEvent
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageEvents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal trace events: %w", err)
+		}
+		events = append(events, pageEvents...)
+	}
+
+	return &Timeline{
+		Invocation: &invocation,
+		Events:     events,
+	}, nil
+}
@@ -0,0 +1,14 @@
+package trace_repository
+
+type TraceRepository interface {
+	CreateInvocation(conversationId, sessionId, input string) (*Invocation, error)
+	// FinishInvocation records how the invocation ended; errorMessage is empty if it succeeded.
+	FinishInvocation(id, finalText, errorMessage string) (*Invocation, error)
+	// AddEvents stores events in addition to the ones already stored for the invocation; their sequence numbers have
+	// to be unique within it.
+	AddEvents(events []*TraceEvent) error
+	// ListInvocationsByConversation returns the conversation's most recent invocations, newest first.
+	ListInvocationsByConversation(conversationId string, limit int) ([]*Invocation, error)
+	// GetTimeline returns nil if the invocation doesn't exist.
+	GetTimeline(invocationId string) (*Timeline, error)
+}
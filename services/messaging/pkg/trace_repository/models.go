@@ -0,0 +1,63 @@
+package trace_repository
+
+// Invocation is one call to the agent, from the input we gave it to its final answer.
+type Invocation struct {
+	Id             string `json:"id" dynamodbav:"id"`
+	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
+	SessionId      string `json:"session_id" dynamodbav:"session_id"`
+	Input          string `json:"input" dynamodbav:"input"`
+	FinalText      string `json:"final_text" dynamodbav:"final_text"`
+	Error          string `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	StartedAt      int64  `json:"started_at" dynamodbav:"started_at"`   // UNIX timestamp in milliseconds
+	FinishedAt     int64  `json:"finished_at" dynamodbav:"finished_at"` // UNIX timestamp in milliseconds; 0 while it's running
+}
+
+type TraceEventType string
+
+const (
+	TraceEventTypeModelInput  TraceEventType = "model_input"
+	TraceEventTypeModelOutput TraceEventType = "model_output"
+	TraceEventTypeRationale   TraceEventType = "rationale"
+	TraceEventTypeToolCall    TraceEventType = "tool_call"
+	TraceEventTypeObservation TraceEventType = "observation"
+	TraceEventTypeGuardrail   TraceEventType = "guardrail"
+	TraceEventTypeFailure     TraceEventType = "failure"
+	TraceEventTypeOther       TraceEventType = "other"
+)
+
+// TraceEvent is one step of an invocation's trace. Which of the optional fields are set depends on the type.
+type TraceEvent struct {
+	InvocationId   string         `json:"invocation_id" dynamodbav:"invocation_id"`
+	Sequence       int            `json:"sequence" dynamodbav:"sequence"` // Order within the invocation, starting at 0.
+	ConversationId string         `json:"conversation_id" dynamodbav:"conversation_id"`
+	Type           TraceEventType `json:"type" dynamodbav:"type"`
+	// TraceId groups the events of one orchestration step (model input, its output, rationale, ...).
+	TraceId     string           `json:"trace_id,omitempty" dynamodbav:"trace_id,omitempty"`
+	Model       string           `json:"model,omitempty" dynamodbav:"model,omitempty"`
+	Messages    []*PromptMessage `json:"messages,omitempty" dynamodbav:"messages,omitempty"`
+	Rationale   string           `json:"rationale,omitempty" dynamodbav:"rationale,omitempty"`
+	ToolCall    *ToolCall        `json:"tool_call,omitempty" dynamodbav:"tool_call,omitempty"`
+	Observation string           `json:"observation,omitempty" dynamodbav:"observation,omitempty"`
+	// Raw is the trace in Bedrock's trace JSON, for anything the fields above don't capture.
+	Raw string `json:"raw,omitempty" dynamodbav:"raw,omitempty"`
+	// Truncated is set when the event was too big to store whole; Raw is dropped and the longest text is cut short.
+	Truncated bool  `json:"truncated,omitempty" dynamodbav:"truncated,omitempty"`
+	At        int64 `json:"at" dynamodbav:"at"` // UNIX timestamp in milliseconds
+}
+
+type PromptMessage struct {
+	Role    string `json:"role" dynamodbav:"role"`
+	Content string `json:"content" dynamodbav:"content"`
+}
+
+type ToolCall struct {
+	ActionGroup string            `json:"action_group" dynamodbav:"action_group"`
+	Function    string            `json:"function" dynamodbav:"function"`
+	Parameters  map[string]string `json:"parameters" dynamodbav:"parameters"`
+}
+
+// Timeline is everything we know about an invocation, with its events in order.
+type Timeline struct {
+	Invocation *Invocation   `json:"invocation"`
+	Events     []*TraceEvent `json:"events"`
+}
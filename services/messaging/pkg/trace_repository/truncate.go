@@ -0,0 +1,70 @@
+package trace_repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// DynamoDB items can't be more than 400 KB, and a batch write fails entirely if any of them is. Sizes are measured as
+// JSON, which is never smaller than DynamoDB's measure, with room left over for the attribute names.
+const maxEventBytes = 350 * 1024
+
+// truncatedMarker is appended to text that was cut short; %d is how many bytes were cut.
+const truncatedMarker = "…[truncated %d bytes]"
+
+// truncateEvent makes the event small enough to store. Full prompts are what make events big: the raw trace goes first
+// since the other fields repeat it, then the longest of the prompt messages, observation and rationale is cut until the
+// event fits.
+func truncateEvent(event *TraceEvent) {
+	if eventSize(event) <= maxEventBytes {
+		return
+	}
+	event.Truncated = true
+	event.Raw = ""
+
+	for {
+		excess := eventSize(event) - maxEventBytes
+		if excess <= 0 {
+			return
+		}
+
+		longest := longestText(event)
+		if longest == nil || len(*longest) <= len(truncatedMarker)*2 {
+			// Nothing left worth cutting; the write will fail and say so.
+			return
+		}
+		*longest = truncateText(*longest, max(len(*longest)-excess-len(truncatedMarker)*2, 0))
+	}
+}
+
+func eventSize(event *TraceEvent) int {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+func longestText(event *TraceEvent) *string {
+	texts := []*string{&event.Observation, &event.Rationale}
+	for _, message := range event.Messages {
+		texts = append(texts, &message.Content)
+	}
+
+	var longest *string
+	for _, text := range texts {
+		if longest == nil || len(*text) > len(*longest) {
+			longest = text
+		}
+	}
+	return longest
+}
+
+// truncateText keeps at most n bytes of text, without splitting a character, and notes how much was cut.
+func truncateText(text string, n int) string {
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + fmt.Sprintf(truncatedMarker, len(text)-n)
+}
@@ -0,0 +1,71 @@
+package trace_repository
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateEvent(t *testing.T) {
+	t.Run("small", func(t *testing.T) {
+		event := &TraceEvent{
+			Type:     TraceEventTypeModelInput,
+			Messages: []*PromptMessage{{Role: "user", Content: "Can someone grab ice?"}},
+			Raw:      `{"orchestrationTrace":{}}`,
+		}
+		truncateEvent(event)
+
+		if event.Truncated || event.Raw == "" || event.Messages[0].Content != "Can someone grab ice?" {
+			t.Errorf("event was changed: %+v", event)
+		}
+	})
+
+	t.Run("large prompt", func(t *testing.T) {
+		system := strings.Repeat("You track to-dos for a group. ", 100)
+		// Multi-byte characters so a cut could land in the middle of one.
+		history := strings.Repeat("Who's bringing the tent? ⛺ ", 30_000)
+		event := &TraceEvent{
+			Type: TraceEventTypeModelInput,
+			Messages: []*PromptMessage{
+				{Role: "system", Content: system},
+				{Role: "user", Content: history},
+				{Role: "assistant", Content: "Noted."},
+			},
+			Raw: strings.Repeat("x", 900_000),
+		}
+		truncateEvent(event)
+
+		if size := eventSize(event); size > maxEventBytes {
+			t.Errorf("size = %d, want at most %d", size, maxEventBytes)
+		}
+		if !event.Truncated {
+			t.Error("truncated = false, want true")
+		}
+		if event.Raw != "" {
+			t.Errorf("raw = %d bytes, want it dropped", len(event.Raw))
+		}
+		if event.Messages[0].Content != system || event.Messages[2].Content != "Noted." {
+			t.Error("short messages were cut")
+		}
+		content := event.Messages[1].Content
+		if !strings.HasPrefix(content, "Who's bringing the tent?") || !strings.Contains(content, "…[truncated ") {
+			t.Errorf("long message = %.40q...%q", content, content[len(content)-40:])
+		}
+		if !utf8.ValidString(content) {
+			t.Error("long message was cut in the middle of a character")
+		}
+	})
+
+	t.Run("large raw", func(t *testing.T) {
+		event := &TraceEvent{
+			Type:        TraceEventTypeObservation,
+			Observation: "task created",
+			Raw:         strings.Repeat("x", 500_000),
+		}
+		truncateEvent(event)
+
+		if !event.Truncated || event.Raw != "" || event.Observation != "task created" {
+			t.Errorf("event = %+v, want only raw dropped", event)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package twilio_webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/conversation"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog"
+)
+
+// MediaStore keeps inbound media; attachment_service.AttachmentService is one.
+type MediaStore interface {
+	Store(ctx context.Context, conversationId, contentType string, data []byte) (*message_repository.Attachment, error)
+}
+
+// AgentScheduler schedules an agent run for a received message; coalesce_service.CoalesceService is one.
+type AgentScheduler interface {
+	MessageReceived(ctx context.Context, conversationId, messageId string) error
+}
+
+// HttpClient downloads inbound media; *http.Client is one.
+type HttpClient interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+type Handler struct {
+	authToken         string
+	agentScheduler    AgentScheduler
+	complianceService *compliance_service.ComplianceService
+	httpClient        HttpClient
+	mediaStore        MediaStore
+	repo              message_repository.MessageRepository
+}
+
+func NewHandler(
+	authToken string,
+	agentScheduler AgentScheduler,
+	complianceService *compliance_service.ComplianceService,
+	httpClient HttpClient,
+	mediaStore MediaStore,
+	repo message_repository.MessageRepository,
+) *Handler {
+	return &Handler{
+		authToken:         authToken,
+		agentScheduler:    agentScheduler,
+		complianceService: complianceService,
+		httpClient:        httpClient,
+		mediaStore:        mediaStore,
+		repo:              repo,
+	}
+}
+
+// HandleRequest processes the webhooks Twilio delivers through a Lambda function URL: inbound messages and, on
+// `/status`, delivery status callbacks for the messages we send.
+func (h *Handler) HandleRequest(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to decode body")
+			return textResponse(http.StatusBadRequest, "invalid body"), nil
+		}
+		body = string(decoded)
+	}
+
+	params, err := url.ParseQuery(body)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to parse body")
+		return textResponse(http.StatusBadRequest, "invalid body"), nil
+	}
+
+	if !ValidateSignature(h.authToken, requestUrl(request), params, request.Headers["x-twilio-signature"]) {
+		logger.Warn().Str("url", requestUrl(request)).Msg("invalid twilio signature")
+		return textResponse(http.StatusForbidden, "invalid signature"), nil
+	}
+
+	switch request.RawPath {
+	case "/status":
+		return h.handleStatusCallback(ctx, request, params)
+	default:
+		return h.handleInbound(ctx, request, params)
+	}
+}
+
+// Twilio doesn't have a notion of a group, so the other participants of the conversation are passed as a comma
+// separated `participants` query string parameter on the webhook URL configured for the Twilio number.
+func (h *Handler) handleInbound(ctx context.Context, request events.LambdaFunctionURLRequest, params url.Values) (events.LambdaFunctionURLResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	inbound := parseInboundMessage(params)
+	logger.Info().Interface("inbound", inbound).Msg("received inbound message")
+
+	phoneNumbers := []string{inbound.From, inbound.To}
+	if participants := request.QueryStringParameters["participants"]; participants != "" {
+		phoneNumbers = append(phoneNumbers, strings.Split(participants, ",")...)
+	}
+
+	conversationId, err := conversationIdForPhoneNumbers(phoneNumbers)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to get conversation ID")
+		return textResponse(http.StatusBadRequest, "invalid phone numbers"), nil
+	}
+
+	from, err := conversation.ToE164(inbound.From)
+	if err != nil {
+		return textResponse(http.StatusBadRequest, "invalid phone numbers"), nil
+	}
+
+	// This has to happen before the agent sees the message; it's done first so a Twilio retry can't skip it.
+	keyword, reply, err := h.complianceService.HandleInbound(ctx, from, inbound.Body)
+	if err != nil {
+		// Let Twilio retry; we can't lose an opt-out.
+		logger.Error().Err(err).Str("from", from).Msg("failed to handle compliance keyword")
+		return events.LambdaFunctionURLResponse{}, fmt.Errorf("failed to handle compliance keyword: %w", err)
+	}
+
+	// Twilio retries a webhook it didn't get a response to, with the same MessageSid. The media was stored (and
+	// described) the first time, so a retry doesn't do that again.
+	message, err := h.repo.GetMessageByExternalId(conversationId, inbound.MessageSid)
+	if err != nil {
+		// Let Twilio retry.
+		logger.Error().Err(err).Msg("failed to get message")
+		return events.LambdaFunctionURLResponse{}, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if message != nil {
+		logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("message already received")
+	} else {
+		attachments := h.storeMedia(ctx, conversationId, inbound)
+
+		var duplicate bool
+		message, duplicate, err = h.repo.CreateMessageWithExternalId(conversationId, inbound.MessageSid, from, inbound.Body, attachments)
+		if err != nil {
+			// Let Twilio retry.
+			logger.Error().Err(err).Msg("failed to create message")
+			return events.LambdaFunctionURLResponse{}, fmt.Errorf("failed to create message: %w", err)
+		}
+
+		if duplicate {
+			logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("message already received")
+		} else {
+			logger.Info().Str("conversation_id", conversationId).Str("message_id", message.Id).Msg("message created")
+		}
+	}
+
+	if keyword != compliance_service.KeywordNone {
+		logger.Info().Str("keyword", string(keyword)).Msg("compliance keyword, not invoking agent")
+		return twimlResponse(reply), nil
+	}
+
+	// This happens for a retry too, in case the first attempt didn't get this far; the pending run only keeps a
+	// message once.
+	if err := h.agentScheduler.MessageReceived(ctx, conversationId, message.Id); err != nil {
+		// The message is saved, so we don't want Twilio to retry and create a duplicate.
+		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("failed to schedule agent run")
+	}
+
+	return twimlResponse(""), nil
+}
+
+// storeMedia keeps whatever media it can; a file we fail to fetch shouldn't cost us the message.
+func (h *Handler) storeMedia(ctx context.Context, conversationId string, inbound InboundMessage) []*message_repository.Attachment {
+	logger := zerolog.Ctx(ctx)
+
+	attachments := []*message_repository.Attachment{}
+	for _, media := range inbound.Media {
+		data, err := h.downloadMedia(ctx, inbound.AccountSid, media)
+		if err != nil {
+			logger.Error().Err(err).Str("media_url", media.Url).Msg("failed to download media")
+			continue
+		}
+
+		attachment, err := h.mediaStore.Store(ctx, conversationId, media.ContentType, data)
+		if err != nil {
+			logger.Error().Err(err).Str("media_url", media.Url).Msg("failed to store media")
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments
+}
+
+// The `message_id` query string parameter is added by the delivery service when it sends the message.
+func (h *Handler) handleStatusCallback(ctx context.Context, request events.LambdaFunctionURLRequest, params url.Values) (events.LambdaFunctionURLResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	callback := parseStatusCallback(params)
+	messageId := request.QueryStringParameters["message_id"]
+	logger.Info().Interface("callback", callback).Str("message_id", messageId).Msg("received status callback")
+
+	if messageId == "" {
+		return textResponse(http.StatusBadRequest, "missing message_id"), nil
+	}
+
+	to, err := conversation.ToE164(callback.To)
+	if err != nil {
+		return textResponse(http.StatusBadRequest, "invalid phone number"), nil
+	}
+
+	status, ok := message_repository.ParseDeliveryStatus(callback.MessageStatus)
+	if !ok {
+		logger.Info().Str("message_status", callback.MessageStatus).Msg("ignoring status we don't track")
+		return twimlResponse(""), nil
+	}
+
+	_, err = h.repo.UpdateDeliveryStatus(
+		messageId,
+		to,
+		callback.MessageSid,
+		status,
+		callback.ErrorCode,
+	)
+	if err != nil {
+		logger.Error().Err(err).Str("message_id", messageId).Msg("failed to update delivery status")
+		return events.LambdaFunctionURLResponse{}, fmt.Errorf("failed to update delivery status: %w", err)
+	}
+
+	return twimlResponse(""), nil
+}
+
+// requestUrl rebuilds the URL Twilio used to call us, which is what the signature is computed over.
+func requestUrl(request events.LambdaFunctionURLRequest) string {
+	u := "https://" + request.RequestContext.DomainName + request.RawPath
+	if request.RawQueryString != "" {
+		u += "?" + request.RawQueryString
+	}
+	return u
+}
+
+// conversationIdForPhoneNumbers removes duplicates (e.g. a participant that's also in the `From`) before building the
+// conversation ID.
+func conversationIdForPhoneNumbers(phoneNumbers []string) (string, error) {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, phoneNumber := range phoneNumbers {
+		phoneNumber = strings.TrimSpace(phoneNumber)
+		if phoneNumber == "" {
+			continue
+		}
+		e164PhoneNumber, err := conversation.ToE164(phoneNumber)
+		if err != nil {
+			return "", err
+		}
+		if seen[e164PhoneNumber] {
+			continue
+		}
+		seen[e164PhoneNumber] = true
+		unique = append(unique, e164PhoneNumber)
+	}
+	return conversation.Id(unique)
+}
+
+// twimlResponse replies to the sender with the message, if there is one. Usually there isn't; the agent decides if it
+// wants to send a message to the conversation.
+func twimlResponse(message string) events.LambdaFunctionURLResponse {
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Response>`)
+	if message != "" {
+		body.WriteString("<Message>")
+		xml.EscapeText(&body, []byte(message))
+		body.WriteString("</Message>")
+	}
+	body.WriteString("</Response>")
+
+	return events.LambdaFunctionURLResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/xml"},
+		Body:       body.String(),
+	}
+}
+
+func textResponse(statusCode int, body string) events.LambdaFunctionURLResponse {
+	return events.LambdaFunctionURLResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Body:       body,
+	}
+}
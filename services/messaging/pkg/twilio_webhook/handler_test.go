@@ -0,0 +1,339 @@
+package twilio_webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/opt_out_repository"
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	testAuthToken  = "12345678901234567890123456789012"
+	testDomainName = "abcdefghij.lambda-url.us-west-2.on.aws"
+	testMediaData  = "not really a jpeg"
+)
+
+// fakeMessageRepository embeds the interface so only the methods the handler uses need implementing.
+type fakeMessageRepository struct {
+	message_repository.MessageRepository
+	messages        map[string]*message_repository.Message
+	statusUpdates   []message_repository.DeliveryStatus
+	statusUpdatesTo []string
+}
+
+func (r *fakeMessageRepository) CreateMessageWithExternalId(conversationId, externalId, from, body string, attachments []*message_repository.Attachment) (*message_repository.Message, bool, error) {
+	if message, ok := r.messages[conversationId+"/"+externalId]; ok {
+		return message, true, nil
+	}
+	message := &message_repository.Message{
+		Id:             "message-" + externalId,
+		ConversationId: conversationId,
+		From:           from,
+		Body:           body,
+		Attachments:    attachments,
+		ExternalId:     externalId,
+	}
+	r.messages[conversationId+"/"+externalId] = message
+	return message, false, nil
+}
+
+func (r *fakeMessageRepository) GetMessageByExternalId(conversationId, externalId string) (*message_repository.Message, error) {
+	return r.messages[conversationId+"/"+externalId], nil
+}
+
+func (r *fakeMessageRepository) UpdateDeliveryStatus(id, to, providerMessageId string, status message_repository.DeliveryStatus, errorCode string) (*message_repository.Message, error) {
+	r.statusUpdates = append(r.statusUpdates, status)
+	r.statusUpdatesTo = append(r.statusUpdatesTo, to)
+	return &message_repository.Message{Id: id}, nil
+}
+
+type fakeOptOutRepository struct {
+	optOuts map[string]*opt_out_repository.OptOut
+}
+
+func (r *fakeOptOutRepository) GetOptOuts(phoneNumbers []string) (map[string]*opt_out_repository.OptOut, error) {
+	optOuts := map[string]*opt_out_repository.OptOut{}
+	for _, phoneNumber := range phoneNumbers {
+		if optOut, ok := r.optOuts[phoneNumber]; ok {
+			optOuts[phoneNumber] = optOut
+		}
+	}
+	return optOuts, nil
+}
+
+func (r *fakeOptOutRepository) SetOptedOut(phoneNumber string, optedOut bool, keyword string) (*opt_out_repository.OptOut, error) {
+	optOut := &opt_out_repository.OptOut{PhoneNumber: phoneNumber, OptedOut: optedOut, Keyword: keyword}
+	r.optOuts[phoneNumber] = optOut
+	return optOut, nil
+}
+
+type fakeMediaStore struct {
+	stored []string
+}
+
+func (s *fakeMediaStore) Store(ctx context.Context, conversationId, contentType string, data []byte) (*message_repository.Attachment, error) {
+	s.stored = append(s.stored, string(data))
+	return &message_repository.Attachment{Id: "attachment-1", ContentType: contentType, Key: conversationId + "/attachment-1"}, nil
+}
+
+type fakeAgentScheduler struct {
+	received []string
+}
+
+func (s *fakeAgentScheduler) MessageReceived(ctx context.Context, conversationId, messageId string) error {
+	s.received = append(s.received, conversationId+"/"+messageId)
+	return nil
+}
+
+// fakeHttpClient serves the media URL in the inbound_media fixture and checks the request is authenticated.
+type fakeHttpClient struct {
+	t *testing.T
+}
+
+func (c *fakeHttpClient) Do(request *http.Request) (*http.Response, error) {
+	accountSid, authToken, ok := request.BasicAuth()
+	if !ok || accountSid != "AC00000000000000000000000000000000" || authToken != testAuthToken {
+		c.t.Errorf("media request isn't authenticated: %q", request.Header.Get("Authorization"))
+	}
+	if !strings.HasPrefix(request.URL.String(), "https://api.twilio.com/") {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(&bytes.Buffer{})}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(testMediaData))}, nil
+}
+
+func TestHandleRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		path      string
+		query     string
+		signature string // empty to sign with ComputeSignature
+		base64    bool
+
+		wantStatus      int
+		wantBody        string
+		wantMessages    int
+		wantScheduled   int
+		wantStored      int
+		wantOptedOut    bool
+		wantStatusCalls int
+	}{
+		{
+			name:          "inbound",
+			fixture:       "inbound.txt",
+			path:          "/",
+			query:         "participants=%2B15555550102",
+			wantStatus:    http.StatusOK,
+			wantBody:      `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`,
+			wantMessages:  1,
+			wantScheduled: 1,
+		},
+		{
+			name:          "base64 encoded inbound",
+			fixture:       "inbound.txt",
+			path:          "/",
+			query:         "participants=%2B15555550102",
+			base64:        true,
+			wantStatus:    http.StatusOK,
+			wantMessages:  1,
+			wantScheduled: 1,
+		},
+		{
+			name:          "inbound with media",
+			fixture:       "inbound_media.txt",
+			path:          "/",
+			query:         "participants=%2B15555550102",
+			wantStatus:    http.StatusOK,
+			wantMessages:  1,
+			wantScheduled: 1,
+			wantStored:    1,
+		},
+		{
+			name:         "stop",
+			fixture:      "stop.txt",
+			path:         "/",
+			query:        "participants=%2B15555550102",
+			wantStatus:   http.StatusOK,
+			wantBody:     `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`,
+			wantMessages: 1,
+			wantOptedOut: true,
+		},
+		{
+			name:            "status callback",
+			fixture:         "status_callback.txt",
+			path:            "/status",
+			query:           "message_id=message-1",
+			wantStatus:      http.StatusOK,
+			wantStatusCalls: 1,
+		},
+		{
+			name:       "status callback without message_id",
+			fixture:    "status_callback.txt",
+			path:       "/status",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad signature",
+			fixture:    "inbound.txt",
+			path:       "/",
+			query:      "participants=%2B15555550102",
+			signature:  "bm90IHRoZSBzaWduYXR1cmU=",
+			wantStatus: http.StatusForbidden,
+			wantBody:   "invalid signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMessageRepository{messages: map[string]*message_repository.Message{}}
+			optOutRepo := &fakeOptOutRepository{optOuts: map[string]*opt_out_repository.OptOut{}}
+			mediaStore := &fakeMediaStore{}
+			scheduler := &fakeAgentScheduler{}
+			handler := NewHandler(
+				testAuthToken,
+				scheduler,
+				compliance_service.NewComplianceService(optOutRepo, compliance_service.DefaultHelpMessage),
+				&fakeHttpClient{t: t},
+				mediaStore,
+				repo,
+			)
+
+			request := fixtureRequest(t, tt.fixture, tt.path, tt.query, tt.signature, tt.base64)
+			response, err := handler.HandleRequest(context.Background(), request)
+			if err != nil {
+				t.Fatalf("HandleRequest() error = %v", err)
+			}
+
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", response.StatusCode, tt.wantStatus, response.Body)
+			}
+			if tt.wantBody != "" && response.Body != tt.wantBody {
+				t.Errorf("body = %q, want %q", response.Body, tt.wantBody)
+			}
+			if len(repo.messages) != tt.wantMessages {
+				t.Errorf("messages = %d, want %d", len(repo.messages), tt.wantMessages)
+			}
+			if len(scheduler.received) != tt.wantScheduled {
+				t.Errorf("scheduled runs = %v, want %d", scheduler.received, tt.wantScheduled)
+			}
+			if len(mediaStore.stored) != tt.wantStored {
+				t.Errorf("stored media = %d, want %d", len(mediaStore.stored), tt.wantStored)
+			}
+			for _, data := range mediaStore.stored {
+				if data != testMediaData {
+					t.Errorf("stored media = %q, want %q", data, testMediaData)
+				}
+			}
+			optOut := optOutRepo.optOuts["+15555550101"]
+			if optedOut := optOut != nil && optOut.OptedOut; optedOut != tt.wantOptedOut {
+				t.Errorf("opted out = %v, want %v", optedOut, tt.wantOptedOut)
+			}
+			if len(repo.statusUpdates) != tt.wantStatusCalls {
+				t.Errorf("status updates = %v, want %d", repo.statusUpdates, tt.wantStatusCalls)
+			}
+			if tt.wantStatusCalls > 0 {
+				if repo.statusUpdates[0] != message_repository.DeliveryStatusDelivered || repo.statusUpdatesTo[0] != "+15555550102" {
+					t.Errorf("status update = %s to %s, want delivered to +15555550102", repo.statusUpdates[0], repo.statusUpdatesTo[0])
+				}
+			}
+
+			for _, message := range repo.messages {
+				if message.ConversationId != "+15555550100_+15555550101_+15555550102" {
+					t.Errorf("conversation ID = %q", message.ConversationId)
+				}
+				if len(message.Attachments) != tt.wantStored {
+					t.Errorf("attachments = %d, want %d", len(message.Attachments), tt.wantStored)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleRequestRetry(t *testing.T) {
+	repo := &fakeMessageRepository{messages: map[string]*message_repository.Message{}}
+	scheduler := &fakeAgentScheduler{}
+	mediaStore := &fakeMediaStore{}
+	handler := NewHandler(
+		testAuthToken,
+		scheduler,
+		compliance_service.NewComplianceService(&fakeOptOutRepository{optOuts: map[string]*opt_out_repository.OptOut{}}, compliance_service.DefaultHelpMessage),
+		&fakeHttpClient{t: t},
+		mediaStore,
+		repo,
+	)
+
+	request := fixtureRequest(t, "inbound_media.txt", "/", "participants=%2B15555550102", "", false)
+	for i := 0; i < 2; i++ {
+		response, err := handler.HandleRequest(context.Background(), request)
+		if err != nil || response.StatusCode != http.StatusOK {
+			t.Fatalf("HandleRequest() = %d, %v", response.StatusCode, err)
+		}
+	}
+
+	if len(repo.messages) != 1 {
+		t.Errorf("messages = %d, want 1", len(repo.messages))
+	}
+	if len(mediaStore.stored) != 1 {
+		t.Errorf("media stored %d times, want 1", len(mediaStore.stored))
+	}
+	// The retry schedules the run again in case the first attempt didn't get to; it's the same message both times.
+	if len(scheduler.received) != 2 || scheduler.received[0] != scheduler.received[1] {
+		t.Errorf("scheduled = %q, want the same message twice", scheduler.received)
+	}
+}
+
+// fixtureRequest builds the Lambda function URL request Twilio would make with the recorded form body.
+func fixtureRequest(t *testing.T, fixture, path, query, signature string, encode bool) events.LambdaFunctionURLRequest {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(data)
+
+	request := events.LambdaFunctionURLRequest{
+		RawPath:        path,
+		RawQueryString: query,
+		Headers:        map[string]string{"content-type": "application/x-www-form-urlencoded"},
+		RequestContext: events.LambdaFunctionURLRequestContext{DomainName: testDomainName},
+	}
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.QueryStringParameters = map[string]string{}
+		for key := range values {
+			request.QueryStringParameters[key] = values.Get(key)
+		}
+	}
+
+	if signature == "" {
+		params, err := url.ParseQuery(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = ComputeSignature(testAuthToken, requestUrl(request), params)
+	}
+	request.Headers["x-twilio-signature"] = signature
+
+	if encode {
+		body = base64.StdEncoding.EncodeToString(data)
+		request.IsBase64Encoded = true
+	}
+	request.Body = body
+
+	return request
+}
@@ -0,0 +1,41 @@
+package twilio_webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Twilio's MMS limit is 5 MB; this leaves some headroom.
+const maxMediaBytes = 10 * 1024 * 1024
+
+// downloadMedia fetches inbound media. If "HTTP Basic Authentication for media access" is enabled on the account, the
+// media URLs require the account's credentials.
+func (h *Handler) downloadMedia(ctx context.Context, accountSid string, media InboundMedia) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, media.Url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.SetBasicAuth(accountSid, h.authToken)
+
+	response, err := h.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download media: status %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxMediaBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media: %w", err)
+	}
+	if len(data) > maxMediaBytes {
+		return nil, fmt.Errorf("media is larger than %d bytes", maxMediaBytes)
+	}
+
+	return data, nil
+}
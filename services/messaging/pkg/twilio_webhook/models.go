@@ -0,0 +1,59 @@
+package twilio_webhook
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// https://www.twilio.com/docs/messaging/guides/webhook-request
+type InboundMessage struct {
+	MessageSid string         `json:"message_sid"`
+	AccountSid string         `json:"account_sid"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Body       string         `json:"body"`
+	Media      []InboundMedia `json:"media"`
+}
+
+type InboundMedia struct {
+	Url         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+func parseInboundMessage(params url.Values) InboundMessage {
+	numMedia, _ := strconv.Atoi(params.Get("NumMedia"))
+	media := make([]InboundMedia, 0, numMedia)
+	for i := 0; i < numMedia; i++ {
+		media = append(media, InboundMedia{
+			Url:         params.Get(fmt.Sprintf("MediaUrl%d", i)),
+			ContentType: params.Get(fmt.Sprintf("MediaContentType%d", i)),
+		})
+	}
+
+	return InboundMessage{
+		MessageSid: params.Get("MessageSid"),
+		AccountSid: params.Get("AccountSid"),
+		From:       params.Get("From"),
+		To:         params.Get("To"),
+		Body:       params.Get("Body"),
+		Media:      media,
+	}
+}
+
+// https://www.twilio.com/docs/messaging/guides/track-outbound-message-status
+type StatusCallback struct {
+	MessageSid    string `json:"message_sid"`
+	MessageStatus string `json:"message_status"`
+	ErrorCode     string `json:"error_code"`
+	To            string `json:"to"`
+}
+
+func parseStatusCallback(params url.Values) StatusCallback {
+	return StatusCallback{
+		MessageSid:    params.Get("MessageSid"),
+		MessageStatus: params.Get("MessageStatus"),
+		ErrorCode:     params.Get("ErrorCode"),
+		To:            params.Get("To"),
+	}
+}
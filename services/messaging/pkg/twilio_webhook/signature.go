@@ -0,0 +1,40 @@
+package twilio_webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// https://www.twilio.com/docs/usage/webhooks/webhooks-security
+func ValidateSignature(authToken, requestUrl string, params url.Values, signature string) bool {
+	expected := ComputeSignature(authToken, requestUrl, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// ComputeSignature returns the value Twilio would send in the `X-Twilio-Signature` header for a form-encoded POST.
+func ComputeSignature(authToken, requestUrl string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(requestUrl)
+	for _, key := range keys {
+		values := append([]string{}, params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			builder.WriteString(key)
+			builder.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(builder.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
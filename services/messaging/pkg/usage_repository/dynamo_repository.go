@@ -0,0 +1,88 @@
+package usage_repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DynamoRepository struct {
+	db        *dynamodb.Client
+	tableName string
+}
+
+func New(ctx context.Context) (UsageRepository, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SDK config: %v", err)
+	}
+
+	db := dynamodb.NewFromConfig(cfg)
+	return &DynamoRepository{
+		db:        db,
+		tableName: "text-agent-usage",
+	}, nil
+}
+
+func (r *DynamoRepository) AddUsage(conversationId, day, model string, inputTokens, outputTokens, costMicros, invocations int64) error {
+	_, err := r.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"conversation_id": &types.AttributeValueMemberS{Value: conversationId},
+			"day_model":       &types.AttributeValueMemberS{Value: dayModel(day, model)},
+		},
+		UpdateExpression: aws.String("SET #day = :day, #model = :model ADD input_tokens :inputTokens, output_tokens :outputTokens, cost_micros :costMicros, invocations :invocations"),
+		ExpressionAttributeNames: map[string]string{
+			"#day":   "day",
+			"#model": "model",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":day":          &types.AttributeValueMemberS{Value: day},
+			":model":        &types.AttributeValueMemberS{Value: model},
+			":inputTokens":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", inputTokens)},
+			":outputTokens": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", outputTokens)},
+			":costMicros":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", costMicros)},
+			":invocations":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", invocations)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DynamoRepository) ListUsageByDay(day string) ([]*Usage, error) {
+	usage := []*Usage{}
+	paginator := dynamodb.NewQueryPaginator(r.db, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("DayIndex"),
+		KeyConditionExpression: aws.String("#day = :day"),
+		ExpressionAttributeNames: map[string]string{
+			"#day": "day",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":day": &types.AttributeValueMemberS{Value: day},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+		}
+
+		var pageUsage []*Usage
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageUsage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+		}
+		usage = append(usage, pageUsage...)
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,8 @@
+package usage_repository
+
+type UsageRepository interface {
+	// AddUsage adds to the conversation's totals for the day and model.
+	AddUsage(conversationId, day, model string, inputTokens, outputTokens, costMicros, invocations int64) error
+	// ListUsageByDay returns every conversation's usage on the day.
+	ListUsageByDay(day string) ([]*Usage, error)
+}
@@ -0,0 +1,20 @@
+package usage_repository
+
+// Usage is what a conversation used of one model on one day (UTC).
+type Usage struct {
+	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
+	DayModel       string `json:"-" dynamodbav:"day_model"` // Sort key; see dayModel.
+	Day            string `json:"day" dynamodbav:"day"`     // YYYY-MM-DD
+	Model          string `json:"model" dynamodbav:"model"`
+	InputTokens    int64  `json:"input_tokens" dynamodbav:"input_tokens"`
+	OutputTokens   int64  `json:"output_tokens" dynamodbav:"output_tokens"`
+	// CostMicros is in millionths of a dollar, priced when the tokens were used.
+	CostMicros int64 `json:"cost_micros" dynamodbav:"cost_micros"`
+	// Invocations counts each invocation once, on the row of the first model it used, so a conversation's rows add up
+	// to its invocations even though one invocation can use several models.
+	Invocations int64 `json:"invocations" dynamodbav:"invocations"`
+}
+
+func dayModel(day, model string) string {
+	return day + "#" + model
+}
@@ -0,0 +1,73 @@
+package usage_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ModelPrice is in dollars per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// Prices maps a model to its price. Traces name models by ID or inference profile ARN, so a key also matches any
+// model it's part of (e.g. "nova-premier" matches "us.amazon.nova-premier-v1:0").
+type Prices map[string]ModelPrice
+
+// https://aws.amazon.com/bedrock/pricing/
+var DefaultPrices = Prices{
+	"nova-premier": {InputPerMillion: 2.50, OutputPerMillion: 12.50},
+	"nova-pro":     {InputPerMillion: 0.80, OutputPerMillion: 3.20},
+	"nova-lite":    {InputPerMillion: 0.06, OutputPerMillion: 0.24},
+	"nova-micro":   {InputPerMillion: 0.035, OutputPerMillion: 0.14},
+}
+
+// ParsePrices reads prices from JSON like `{"nova-premier": {"input_per_million": 2.5, "output_per_million": 12.5}}`;
+// they're added to (and override) the defaults. An empty string means just the defaults.
+func ParsePrices(s string) (Prices, error) {
+	prices := Prices{}
+	for model, price := range DefaultPrices {
+		prices[model] = price
+	}
+
+	if s == "" {
+		return prices, nil
+	}
+
+	var configured Prices
+	if err := json.Unmarshal([]byte(s), &configured); err != nil {
+		return nil, fmt.Errorf("invalid model prices: %w", err)
+	}
+	for model, price := range configured {
+		prices[model] = price
+	}
+
+	return prices, nil
+}
+
+// priceFor prefers an exact match, then the longest key the model contains. The bool is false if nothing matches.
+func (p Prices) priceFor(model string) (ModelPrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+
+	match := ""
+	for key := range p {
+		if strings.Contains(model, key) && len(key) > len(match) {
+			match = key
+		}
+	}
+	if match == "" {
+		return ModelPrice{}, false
+	}
+
+	return p[match], true
+}
+
+// costMicros is in millionths of a dollar; prices are per million tokens, so it's just tokens times price.
+func (p ModelPrice) costMicros(inputTokens, outputTokens int64) int64 {
+	return int64(math.Round(float64(inputTokens)*p.InputPerMillion + float64(outputTokens)*p.OutputPerMillion))
+}
@@ -0,0 +1,127 @@
+package usage_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_repository"
+	"github.com/rs/zerolog"
+)
+
+const dayFormat = "2006-01-02"
+
+// ModelUsage is the tokens one model used during an agent invocation.
+type ModelUsage struct {
+	Model        string `json:"model"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+}
+
+// ConversationCost is a conversation's usage over a report's date range.
+type ConversationCost struct {
+	ConversationId string  `json:"conversation_id"`
+	InputTokens    int64   `json:"input_tokens"`
+	OutputTokens   int64   `json:"output_tokens"`
+	CostMicros     int64   `json:"cost_micros"`
+	Cost           float64 `json:"cost"` // Dollars.
+	Invocations    int64   `json:"invocations"`
+}
+
+// UsageService keeps track of how many tokens, and dollars, each conversation costs us.
+type UsageService struct {
+	prices Prices
+	repo   usage_repository.UsageRepository
+}
+
+func NewUsageService(prices Prices, repo usage_repository.UsageRepository) *UsageService {
+	return &UsageService{
+		prices: prices,
+		repo:   repo,
+	}
+}
+
+// Record adds an invocation's usage to the conversation's totals for the day (UTC) it happened on. Usage of a model
+// without a price is recorded without a cost.
+func (s *UsageService) Record(ctx context.Context, conversationId string, at time.Time, usage []*ModelUsage) error {
+	logger := zerolog.Ctx(ctx)
+
+	day := at.UTC().Format(dayFormat)
+	for i, u := range usage {
+		price, ok := s.prices.priceFor(u.Model)
+		if !ok {
+			logger.Warn().Str("model", u.Model).Msg("no price for model")
+		}
+
+		// The invocation is counted once, with the first model's usage.
+		invocations := int64(0)
+		if i == 0 {
+			invocations = 1
+		}
+
+		err := s.repo.AddUsage(conversationId, day, u.Model, u.InputTokens, u.OutputTokens, price.costMicros(u.InputTokens, u.OutputTokens), invocations)
+		if err != nil {
+			return fmt.Errorf("failed to add usage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TopConversations returns the conversations that cost the most between the days (UTC, inclusive), most expensive
+// first.
+func (s *UsageService) TopConversations(ctx context.Context, from, to time.Time, limit int) ([]*ConversationCost, error) {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	if to.Before(from) {
+		return nil, fmt.Errorf("the end of the range is before its start")
+	}
+
+	costs := map[string]*ConversationCost{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		usage, err := s.repo.ListUsageByDay(day.Format(dayFormat))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list usage: %w", err)
+		}
+
+		for _, u := range usage {
+			cost, ok := costs[u.ConversationId]
+			if !ok {
+				cost = &ConversationCost{ConversationId: u.ConversationId}
+				costs[u.ConversationId] = cost
+			}
+			cost.InputTokens += u.InputTokens
+			cost.OutputTokens += u.OutputTokens
+			cost.CostMicros += u.CostMicros
+			cost.Invocations += u.Invocations
+		}
+	}
+
+	top := []*ConversationCost{}
+	for _, cost := range costs {
+		cost.Cost = float64(cost.CostMicros) / 1_000_000
+		top = append(top, cost)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].CostMicros != top[j].CostMicros {
+			return top[i].CostMicros > top[j].CostMicros
+		}
+		return top[i].ConversationId < top[j].ConversationId
+	})
+
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top, nil
+}
+
+// ParseDay parses a YYYY-MM-DD day as UTC.
+func ParseDay(s string) (time.Time, error) {
+	day, err := time.Parse(dayFormat, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day (expected YYYY-MM-DD): %s", s)
+	}
+	return day, nil
+}
@@ -0,0 +1,91 @@
+package usage_service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_repository"
+)
+
+// fakeUsageRepository adds usage up the same way the Dynamo repository's ADD does.
+type fakeUsageRepository struct {
+	usage map[string]*usage_repository.Usage
+}
+
+func (r *fakeUsageRepository) AddUsage(conversationId, day, model string, inputTokens, outputTokens, costMicros, invocations int64) error {
+	key := conversationId + "/" + day + "/" + model
+	u, ok := r.usage[key]
+	if !ok {
+		u = &usage_repository.Usage{ConversationId: conversationId, Day: day, Model: model}
+		r.usage[key] = u
+	}
+	u.InputTokens += inputTokens
+	u.OutputTokens += outputTokens
+	u.CostMicros += costMicros
+	u.Invocations += invocations
+	return nil
+}
+
+func (r *fakeUsageRepository) ListUsageByDay(day string) ([]*usage_repository.Usage, error) {
+	usage := []*usage_repository.Usage{}
+	for _, u := range r.usage {
+		if u.Day == day {
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeUsageRepository{usage: map[string]*usage_repository.Usage{}}
+	service := NewUsageService(DefaultPrices, repo)
+	at := time.Date(2025, 8, 22, 18, 0, 0, 0, time.UTC)
+
+	// An invocation that used two models, then one that used one, then one that didn't get as far as a model.
+	invocations := [][]*ModelUsage{
+		{
+			{Model: "us.amazon.nova-pro-v1:0", InputTokens: 1_000_000, OutputTokens: 100_000},
+			{Model: "us.amazon.nova-micro-v1:0", InputTokens: 2_000, OutputTokens: 100},
+		},
+		{
+			{Model: "us.amazon.nova-pro-v1:0", InputTokens: 1_000, OutputTokens: 100},
+		},
+		{},
+	}
+	for _, usage := range invocations {
+		if err := service.Record(ctx, "a_b", at, usage); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := service.Record(ctx, "c_d", at, []*ModelUsage{{Model: "unpriced", InputTokens: 10, OutputTokens: 1}}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	pro := repo.usage["a_b/2025-08-22/us.amazon.nova-pro-v1:0"]
+	if pro == nil || pro.Invocations != 2 || pro.InputTokens != 1_001_000 || pro.OutputTokens != 100_100 {
+		t.Errorf("nova pro usage = %+v", pro)
+	}
+	micro := repo.usage["a_b/2025-08-22/us.amazon.nova-micro-v1:0"]
+	if micro == nil || micro.Invocations != 0 {
+		t.Errorf("nova micro usage = %+v, want no invocations counted", micro)
+	}
+
+	top, err := service.TopConversations(ctx, at, at, 0)
+	if err != nil {
+		t.Fatalf("TopConversations() error = %v", err)
+	}
+	if len(top) != 2 {
+		t.Fatalf("TopConversations() = %d conversations, want 2", len(top))
+	}
+
+	// 1,001,000 input tokens at $0.80 and 100,100 output tokens at $3.20 per million, plus the micro model's.
+	wantCostMicros := int64(800_800) + int64(320_320) + int64(70) + int64(14)
+	if top[0].ConversationId != "a_b" || top[0].Invocations != 2 || top[0].CostMicros != wantCostMicros {
+		t.Errorf("top[0] = %+v, want a_b with 2 invocations costing %d", top[0], wantCostMicros)
+	}
+	if top[1].ConversationId != "c_d" || top[1].Invocations != 1 || top[1].CostMicros != 0 {
+		t.Errorf("top[1] = %+v, want c_d with 1 invocation and no cost", top[1])
+	}
+}
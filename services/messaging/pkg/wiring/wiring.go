@@ -0,0 +1,223 @@
+// Package wiring builds the services more than one of the Lambdas needs, configured from the environment variables
+// they share, so each main only wires up what's particular to it.
+package wiring
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/agent_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/attachment_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/blob_store"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/coalesce_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/compliance_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/delivery_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/job_queue"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/media_describer"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/message_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/opt_out_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/pending_run_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/rate_limit_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/rate_limit_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_policy_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/reminder_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/secrets_service"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/session_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/sms_sender"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/trace_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_repository"
+	"github.com/anthonywittig/text-agent/services/messaging/pkg/usage_service"
+)
+
+// RequiredEnv returns the environment variable, or an error if it isn't set.
+func RequiredEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return value, nil
+}
+
+// Secret returns the secret whose ID is in the environment variable.
+func Secret(ctx context.Context, secretsService secrets_service.SecretsService, secretIdEnv string) (string, error) {
+	secretId, err := RequiredEnv(secretIdEnv)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := secretsService.GetSecret(ctx, secretId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", secretIdEnv, err)
+	}
+	return secret, nil
+}
+
+func ComplianceService(ctx context.Context) (*compliance_service.ComplianceService, error) {
+	optOutRepo, err := opt_out_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create opt out repository: %w", err)
+	}
+
+	return compliance_service.NewComplianceService(optOutRepo, compliance_service.DefaultHelpMessage), nil
+}
+
+// DeliveryService texts through Twilio; TWILIO_STATUS_CALLBACK_URL is optional, without it we don't hear back about
+// delivery status.
+func DeliveryService(ctx context.Context, secretsService secrets_service.SecretsService, complianceService *compliance_service.ComplianceService, repo message_repository.MessageRepository) (*delivery_service.DeliveryService, error) {
+	twilioAccountSid, err := RequiredEnv("TWILIO_ACCOUNT_SID")
+	if err != nil {
+		return nil, err
+	}
+
+	twilioFromNumber, err := RequiredEnv("TWILIO_FROM_NUMBER")
+	if err != nil {
+		return nil, err
+	}
+
+	twilioAuthToken, err := Secret(ctx, secretsService, "TWILIO_AUTH_TOKEN_SECRET_ID")
+	if err != nil {
+		return nil, err
+	}
+
+	smsSender := sms_sender.NewTwilio(twilioAccountSid, twilioAuthToken, twilioFromNumber)
+	twilioStatusCallbackUrl := os.Getenv("TWILIO_STATUS_CALLBACK_URL")
+	return delivery_service.NewDeliveryService(complianceService, repo, smsSender, twilioFromNumber, twilioStatusCallbackUrl), nil
+}
+
+// AttachmentService keeps attachments in ATTACHMENTS_BUCKET and has them described by MEDIA_DESCRIBER_MODEL_ID.
+func AttachmentService(ctx context.Context) (*attachment_service.AttachmentService, error) {
+	attachmentsBucket, err := RequiredEnv("ATTACHMENTS_BUCKET")
+	if err != nil {
+		return nil, err
+	}
+
+	mediaDescriberModelId, err := RequiredEnv("MEDIA_DESCRIBER_MODEL_ID")
+	if err != nil {
+		return nil, err
+	}
+
+	blobStore, err := blob_store.NewS3(ctx, attachmentsBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob store: %w", err)
+	}
+
+	mediaDescriber, err := media_describer.NewBedrock(ctx, mediaDescriberModelId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create media describer: %w", err)
+	}
+
+	return attachment_service.NewAttachmentService(blobStore, mediaDescriber), nil
+}
+
+// CoalesceService enqueues agent runs on JOB_QUEUE_URL, which the worker consumes.
+func CoalesceService(ctx context.Context) (*coalesce_service.CoalesceService, error) {
+	jobQueueUrl, err := RequiredEnv("JOB_QUEUE_URL")
+	if err != nil {
+		return nil, err
+	}
+
+	pendingRunRepo, err := pending_run_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pending run repository: %w", err)
+	}
+
+	coalesceWindow, err := coalesce_service.ParseWindow(os.Getenv("AGENT_COALESCE_WINDOW"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse coalesce window: %w", err)
+	}
+
+	jobQueue, err := job_queue.NewSqs(ctx, jobQueueUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job queue: %w", err)
+	}
+
+	return coalesce_service.NewCoalesceService(jobQueue, pendingRunRepo, coalesceWindow), nil
+}
+
+func ReminderPolicyService(ctx context.Context) (*reminder_service.PolicyService, error) {
+	reminderPolicyRepo, err := reminder_policy_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder policy repository: %w", err)
+	}
+
+	defaultReminderPolicy, err := reminder_service.ParsePolicy(
+		os.Getenv("REMINDER_LEAD_TIME"),
+		os.Getenv("REMINDER_REPEAT_EVERY"),
+		os.Getenv("REMINDER_QUIET_HOURS"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reminder policy: %w", err)
+	}
+
+	return reminder_service.NewPolicyService(defaultReminderPolicy, reminderPolicyRepo), nil
+}
+
+func RateLimitService(ctx context.Context) (*rate_limit_service.RateLimitService, error) {
+	rateLimitRepo, err := rate_limit_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limit repository: %w", err)
+	}
+
+	rateLimitPolicy, err := rate_limit_service.ParsePolicy(
+		os.Getenv("AGENT_RATE_LIMIT_BURST"),
+		os.Getenv("AGENT_RATE_LIMIT_REFILL_EVERY"),
+		os.Getenv("AGENT_DAILY_BUDGET"),
+		os.Getenv("AGENT_LIMIT_BEHAVIOR"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit policy: %w", err)
+	}
+
+	return rate_limit_service.NewRateLimitService(rateLimitPolicy, rateLimitRepo), nil
+}
+
+func UsageService(ctx context.Context) (*usage_service.UsageService, error) {
+	usageRepo, err := usage_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create usage repository: %w", err)
+	}
+
+	modelPrices, err := usage_service.ParsePrices(os.Getenv("MODEL_PRICES"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model prices: %w", err)
+	}
+
+	return usage_service.NewUsageService(modelPrices, usageRepo), nil
+}
+
+// AgentService invokes the Bedrock agent in AGENT_ID_SECRET_ID and AGENT_ALIAS_ID_SECRET_ID, with its sessions,
+// traces and usage recorded.
+func AgentService(ctx context.Context, secretsService secrets_service.SecretsService, dispatcher agent_service.ActionDispatcher) (agent_service.AgentService, error) {
+	agentAliasId, err := Secret(ctx, secretsService, "AGENT_ALIAS_ID_SECRET_ID")
+	if err != nil {
+		return nil, err
+	}
+
+	agentId, err := Secret(ctx, secretsService, "AGENT_ID_SECRET_ID")
+	if err != nil {
+		return nil, err
+	}
+
+	sessionRepo, err := session_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session repository: %w", err)
+	}
+
+	sessionPolicy, err := agent_service.ParseSessionPolicy(os.Getenv("AGENT_SESSION_MAX_INVOCATIONS"), os.Getenv("AGENT_SESSION_IDLE_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent session policy: %w", err)
+	}
+
+	traceRepo, err := trace_repository.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace repository: %w", err)
+	}
+
+	usageService, err := UsageService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent_service.NewAws(ctx, agentAliasId, agentId, dispatcher, sessionRepo, sessionPolicy, traceRepo, usageService)
+}
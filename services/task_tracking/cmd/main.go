@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	_ "time/tzdata" // Due dates are resolved in the conversation's timezone.
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/rs/zerolog"
 
 	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/agent_action_consumer"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
 	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
 )
 
@@ -23,7 +25,19 @@ func main() {
 		logger.Fatal().Err(err).Msg("failed to create repository")
 	}
 
-	consumer := agent_action_consumer.NewConsumer(repo)
+	dedupWindow, err := agent_action_consumer.ParseDedupWindow(os.Getenv("TASK_DEDUP_WINDOW"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse task dedup window")
+	}
+
+	defaultLocation, err := due_date.ParseLocation(os.Getenv("DEFAULT_TIMEZONE"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to parse default timezone")
+	}
+
+	// Only the messaging worker can look messages up; here relative due dates are worked out from the sent time the
+	// agent passes, in the default timezone.
+	consumer := agent_action_consumer.NewConsumer(due_date.NewClock(defaultLocation), dedupWindow, repo)
 
 	requestWrapper := func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
 		lc, _ := lambdacontext.FromContext(ctx)
@@ -2,17 +2,25 @@ package agent_action_consumer
 
 import (
 	"context"
+	"time"
 
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
 	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
 	"github.com/rs/zerolog"
 )
 
 type Consumer struct {
-	repo task_repository.TaskRepository
+	anchorSource due_date.AnchorSource
+	dedupWindow  time.Duration
+	repo         task_repository.TaskRepository
 }
 
-func NewConsumer(repo task_repository.TaskRepository) *Consumer {
-	return &Consumer{repo: repo}
+func NewConsumer(anchorSource due_date.AnchorSource, dedupWindow time.Duration, repo task_repository.TaskRepository) *Consumer {
+	return &Consumer{
+		anchorSource: anchorSource,
+		dedupWindow:  dedupWindow,
+		repo:         repo,
+	}
 }
 
 func (c *Consumer) HandleRequest(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
@@ -21,10 +29,18 @@ func (c *Consumer) HandleRequest(ctx context.Context, payload AgentRequest) (Age
 	switch payload.Function {
 	case "task_tracking_create":
 		return c.handleTaskTrackingCreate(ctx, payload)
+	case "task_tracking_update":
+		return c.handleTaskTrackingUpdate(ctx, payload)
+	case "task_tracking_complete":
+		return c.handleTaskTrackingComplete(ctx, payload)
+	case "task_tracking_cancel":
+		return c.handleTaskTrackingCancel(ctx, payload)
 	case "task_tracking_delete":
 		return c.handleTaskTrackingDelete(ctx, payload)
 	case "task_tracking_list":
 		return c.handleTaskTrackingList(ctx, payload)
+	case "task_tracking_list_by_assignee":
+		return c.handleTaskTrackingListByAssignee(ctx, payload)
 	default:
 		logger.Error().Str("action_group", payload.ActionGroup).Msg("unknown action group")
 		return AgentResponse{
@@ -0,0 +1,40 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+)
+
+// getDueDate resolves the `due` parameter, an ISO date or a phrase like "by Friday", into a date (YYYY-MM-DD). It
+// returns the phrase too, trimmed, so people can check the date against what was said. Relative phrases are resolved
+// against when the source message (`source_message_id` and `source_sent_at`) was sent, or now if it wasn't passed.
+func (c *Consumer) getDueDate(ctx context.Context, payload AgentRequest, conversationId string) (dueOn, duePhrase string, err error) {
+	duePhrase = strings.TrimSpace(getParameter(payload, "due"))
+	if duePhrase == "" {
+		return "", "", nil
+	}
+
+	source := due_date.Source{MessageId: getParameter(payload, "source_message_id")}
+	if sentAt := getParameter(payload, "source_sent_at"); sentAt != "" {
+		source.SentAt, err = time.Parse(time.RFC3339, sentAt)
+		if err != nil {
+			return "", "", errors.New("source_sent_at must be a time like 2025-08-22T15:04:05Z")
+		}
+	}
+
+	anchor, err := c.anchorSource.Anchor(ctx, conversationId, source)
+	if err != nil {
+		return "", "", err
+	}
+
+	dueOn, err = due_date.Resolve(duePhrase, anchor)
+	if err != nil {
+		return "", "", errors.New("couldn't work out a date from due: " + duePhrase + "; pass a date like 2025-08-23 instead")
+	}
+
+	return dueOn, duePhrase, nil
+}
@@ -0,0 +1,79 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+)
+
+// TestGetDueDateWithClock covers the task tracking Lambda, which can't look messages up and works from the sent time
+// the agent passes.
+func TestGetDueDateWithClock(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer := NewConsumer(due_date.NewClock(denver), 0, &fakeTaskRepository{})
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{
+			// Friday in UTC, but still Thursday in Denver.
+			name:       "sent time",
+			parameters: map[string]string{"due": "today", "source_sent_at": "2025-08-22T03:00:00Z"},
+			want:       "2025-08-21",
+		},
+		{
+			name:       "message ID with its sent time",
+			parameters: map[string]string{"due": "by Saturday", "source_message_id": "m1", "source_sent_at": "2025-08-22T15:00:00Z"},
+			want:       "2025-08-23",
+		},
+		{
+			name:       "message ID without its sent time",
+			parameters: map[string]string{"due": "by Saturday", "source_message_id": "m1"},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid sent time",
+			parameters: map[string]string{"due": "by Saturday", "source_sent_at": "Friday morning"},
+			wantErr:    true,
+		},
+		{
+			name:       "date",
+			parameters: map[string]string{"due": "2025-09-01", "source_message_id": "m1", "source_sent_at": "2025-08-22T15:00:00Z"},
+			want:       "2025-09-01",
+		},
+		{
+			name:       "no due date",
+			parameters: map[string]string{"source_message_id": "m1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dueOn, _, err := consumer.getDueDate(context.Background(), agentRequest(t, "task_tracking_create", tt.parameters), conversationId)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("getDueDate() = %q, want an error", dueOn)
+				}
+				return
+			}
+			if err != nil || dueOn != tt.want {
+				t.Errorf("getDueDate() = %q, %v, want %q", dueOn, err, tt.want)
+			}
+		})
+	}
+
+	// Without a source it's worked out from now.
+	dueOn, _, err := consumer.getDueDate(context.Background(), agentRequest(t, "task_tracking_create", map[string]string{"due": "today"}), conversationId)
+	if want := time.Now().In(denver).Format(time.DateOnly); err != nil || dueOn != want {
+		t.Errorf("getDueDate() = %q, %v, want %q", dueOn, err, want)
+	}
+}
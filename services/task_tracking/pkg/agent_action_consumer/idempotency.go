@@ -0,0 +1,43 @@
+package agent_action_consumer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultDedupWindow is how long a repeated task_tracking_create returns the original task instead of creating another.
+const DefaultDedupWindow = 10 * time.Minute
+
+// ParseDedupWindow parses a duration like "10m"; empty keeps the default.
+func ParseDedupWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return DefaultDedupWindow, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid dedup window: %s", window)
+	}
+
+	return d, nil
+}
+
+// taskIdempotencyKey is the caller's key if it passed one. Otherwise it's derived from the agent's session and the
+// task's name and source, which is what stays the same when Bedrock retries a call or repeats it within an
+// orchestration (the description tends to get reworded).
+func taskIdempotencyKey(payload AgentRequest, conversationId string) string {
+	if key := getParameter(payload, "idempotency_key"); key != "" {
+		return conversationId + ":key:" + key
+	}
+
+	hash := sha256.Sum256([]byte(payload.SessionId + "\x00" + normalize(getParameter(payload, "name")) + "\x00" + normalize(getParameter(payload, "source"))))
+	return conversationId + ":session:" + hex.EncodeToString(hash[:])
+}
+
+// normalize ignores case and whitespace differences.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
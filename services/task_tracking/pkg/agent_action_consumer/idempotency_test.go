@@ -0,0 +1,71 @@
+package agent_action_consumer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskIdempotencyKey(t *testing.T) {
+	const conversationId = "+15555550100_+15555550101"
+	request := func(sessionId string, parameters map[string]string) AgentRequest {
+		r := agentRequest(t, "task_tracking_create", parameters)
+		r.SessionId = sessionId
+		return r
+	}
+	base := request("s1", map[string]string{"name": "Buy ice", "source": "Joe: I'll grab ice", "description": "Two bags"})
+	baseKey := taskIdempotencyKey(base, conversationId)
+
+	tests := []struct {
+		name     string
+		request  AgentRequest
+		wantSame bool
+	}{
+		{
+			name:     "case and whitespace",
+			request:  request("s1", map[string]string{"name": "  buy   ICE ", "source": "joe: i'll grab ice"}),
+			wantSame: true,
+		},
+		{
+			name:     "reworded description",
+			request:  request("s1", map[string]string{"name": "Buy ice", "source": "Joe: I'll grab ice", "description": "A couple of bags"}),
+			wantSame: true,
+		},
+		{
+			name:    "another session",
+			request: request("s2", map[string]string{"name": "Buy ice", "source": "Joe: I'll grab ice"}),
+		},
+		{
+			name:    "another name",
+			request: request("s1", map[string]string{"name": "Buy firewood", "source": "Joe: I'll grab ice"}),
+		},
+		{
+			name:    "another source",
+			request: request("s1", map[string]string{"name": "Buy ice", "source": "Sam: can someone grab ice?"}),
+		},
+		{
+			name:    "explicit key",
+			request: request("s1", map[string]string{"name": "Buy ice", "source": "Joe: I'll grab ice", "idempotency_key": "ice"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := taskIdempotencyKey(tt.request, conversationId)
+			if (key == baseKey) != tt.wantSame {
+				t.Errorf("taskIdempotencyKey() = %s, same as %s is %v, want %v", key, baseKey, key == baseKey, tt.wantSame)
+			}
+		})
+	}
+
+	// An explicit key is used as is, whatever the session and task, but only within the conversation.
+	explicit := taskIdempotencyKey(request("s1", map[string]string{"name": "Buy ice", "idempotency_key": "ice"}), conversationId)
+	if other := taskIdempotencyKey(request("s2", map[string]string{"name": "Buy firewood", "idempotency_key": "ice"}), conversationId); other != explicit {
+		t.Errorf("taskIdempotencyKey() = %s, want %s", other, explicit)
+	}
+	if other := taskIdempotencyKey(request("s1", map[string]string{"name": "Buy ice", "idempotency_key": "ice"}), "+15555550100_+15555550199"); other == explicit {
+		t.Errorf("taskIdempotencyKey() for another conversation = %s, want a different key", other)
+	}
+	if !strings.HasPrefix(explicit, conversationId+":key:") || !strings.HasPrefix(baseKey, conversationId+":session:") {
+		t.Errorf("taskIdempotencyKey() = %s and %s, want them scoped to the conversation", explicit, baseKey)
+	}
+}
@@ -0,0 +1,30 @@
+package agent_action_consumer
+
+import (
+	"context"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/rs/zerolog"
+)
+
+func (c *Consumer) handleTaskTrackingCancel(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleTaskTrackingCancel")
+
+	task, err := c.getConversationTask(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+	// A completed task keeps its history; canceling a canceled task again is harmless.
+	if task.Status == task_repository.TaskStatusCompleted {
+		return getFailureResponse(payload, "Task is completed and can't be canceled"), nil
+	}
+
+	task, err = c.repo.SetTaskStatus(task.Id, task_repository.TaskStatusCanceled, "")
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return taskStatusResponse(payload, "Task canceled successfully", task)
+}
@@ -0,0 +1,73 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/rs/zerolog"
+)
+
+type TaskTrackingStatusResponse struct {
+	Message string                `json:"message"`
+	Task    *task_repository.Task `json:"task"`
+}
+
+func (c *Consumer) handleTaskTrackingComplete(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleTaskTrackingComplete")
+
+	// The agent is asked for the date in the conversation's terms; today (UTC) is only a fallback.
+	completedOn := getParameter(payload, "completed_on")
+	if completedOn == "" {
+		completedOn = time.Now().UTC().Format(time.DateOnly)
+	}
+	if _, err := time.Parse(time.DateOnly, completedOn); err != nil {
+		return getFailureResponse(payload, "completed_on must be a date like 2025-08-23"), nil
+	}
+
+	task, err := c.getConversationTask(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+	// Completing a completed task again only changes its date.
+	if task.Status == task_repository.TaskStatusCanceled {
+		return getFailureResponse(payload, "Task is canceled and can't be completed"), nil
+	}
+
+	task, err = c.repo.SetTaskStatus(task.Id, task_repository.TaskStatusCompleted, completedOn)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return taskStatusResponse(payload, "Task completed successfully", task)
+}
+
+func taskStatusResponse(payload AgentRequest, message string, task *task_repository.Task) (AgentResponse, error) {
+	response := TaskTrackingStatusResponse{
+		Message: message,
+		Task:    task,
+	}
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return AgentResponse{
+		MessageVersion: "1.0",
+		Response: AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -3,6 +3,7 @@ package agent_action_consumer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
 	"github.com/rs/zerolog"
@@ -11,6 +12,8 @@ import (
 type TaskTrackingCreateResponse struct {
 	Message string                `json:"message"`
 	Task    *task_repository.Task `json:"task"`
+	// Deduplicated is true when the task had already been created and the original was returned.
+	Deduplicated bool `json:"deduplicated,omitempty"`
 }
 
 func (c *Consumer) handleTaskTrackingCreate(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
@@ -23,12 +26,26 @@ func (c *Consumer) handleTaskTrackingCreate(ctx context.Context, payload AgentRe
 		return getFailureResponse(payload, err.Error()), nil
 	}
 
-	task, err := c.repo.CreateTask(
-		conversationId,
-		getParameter(payload, "name"),
-		getParameter(payload, "description"),
-		getParameter(payload, "source"),
-	)
+	assignee, err := getAssignee(payload, conversationId)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	dueOn, duePhrase, err := c.getDueDate(ctx, payload, conversationId)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	draft := &task_repository.Task{
+		ConversationId: conversationId,
+		Name:           getParameter(payload, "name"),
+		Description:    getParameter(payload, "description"),
+		Source:         getParameter(payload, "source"),
+		Assignee:       assignee,
+		DueOn:          dueOn,
+		DuePhrase:      duePhrase,
+	}
+	task, deduplicated, err := c.repo.CreateTaskIdempotent(draft, taskIdempotencyKey(payload, conversationId), c.dedupWindow)
 	if err != nil {
 		return getFailureResponse(payload, err.Error()), nil
 	}
@@ -37,6 +54,14 @@ func (c *Consumer) handleTaskTrackingCreate(ctx context.Context, payload AgentRe
 		Message: "Task created successfully",
 		Task:    task,
 	}
+	if task.DueOn != "" {
+		response.Message = fmt.Sprintf("Task created successfully, due %s (%q); confirm the date with the conversation", task.DueOn, task.DuePhrase)
+	}
+	if deduplicated {
+		logger.Info().Str("task_id", task.Id).Msg("task already created, returning the original")
+		response.Message = "This task was already created; returning the original task instead of creating a duplicate"
+		response.Deduplicated = true
+	}
 	responseJson, err := json.Marshal(response)
 	if err != nil {
 		return getFailureResponse(payload, err.Error()), nil
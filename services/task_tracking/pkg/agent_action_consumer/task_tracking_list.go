@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
 	"github.com/rs/zerolog"
 )
 
@@ -17,12 +18,25 @@ func (c *Consumer) handleTaskTrackingList(ctx context.Context, payload AgentRequ
 
 	logger.Info().Str("conversation_id", conversationId).Msg("Processing conversation")
 
+	// Only open tasks unless asked otherwise; that's what the agent works from.
+	status := getParameter(payload, "status")
+	if status == "" {
+		status = string(task_repository.TaskStatusOpen)
+	}
+	if status != "all" && !task_repository.TaskStatus(status).Valid() {
+		return getFailureResponse(payload, "status must be one of open, completed, canceled or all"), nil
+	}
+
 	tasks, err := c.repo.ListTasksByConversation(conversationId)
 	if err != nil {
 		logger.Error().Err(err).Str("conversation_id", conversationId).Msg("Failed to list tasks")
 		return getFailureResponse(payload, "Internal error"), nil
 	}
 
+	if status != "all" {
+		tasks = filterTasksByStatus(tasks, task_repository.TaskStatus(status))
+	}
+
 	taskString, err := json.Marshal(tasks)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to marshal tasks")
@@ -44,3 +58,13 @@ func (c *Consumer) handleTaskTrackingList(ctx context.Context, payload AgentRequ
 		},
 	}, nil
 }
+
+func filterTasksByStatus(tasks []*task_repository.Task, status task_repository.TaskStatus) []*task_repository.Task {
+	filtered := []*task_repository.Task{}
+	for _, task := range tasks {
+		if task.Status == status {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
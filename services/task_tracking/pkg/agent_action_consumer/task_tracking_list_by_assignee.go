@@ -0,0 +1,99 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/rs/zerolog"
+)
+
+// assigneeTasks is the assignee's list. Tasks from their other conversations keep their name, since "what's on my
+// plate?" can't be answered without it, and the assignee is in those conversations too. Everyone else in this
+// conversation sees the names as well; that's the trade-off. Descriptions, sources and who else is in the other
+// conversations aren't shared.
+type assigneeTasks struct {
+	Tasks                  []*task_repository.Task  `json:"tasks"`
+	OtherConversationTasks []*otherConversationTask `json:"other_conversation_tasks"`
+}
+
+type otherConversationTask struct {
+	Name        string                     `json:"name"`
+	Status      task_repository.TaskStatus `json:"status"`
+	DueOn       string                     `json:"due_on,omitempty"`
+	CompletedOn string                     `json:"completed_on,omitempty"`
+}
+
+// handleTaskTrackingListByAssignee answers "what's on my plate?"; it covers all of the assignee's conversations, not
+// just the one the agent is working on. The assignee has to be in this conversation.
+func (c *Consumer) handleTaskTrackingListByAssignee(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	assignee, err := conversationParticipant(conversationId, getParameter(payload, "assignee"))
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	status := getParameter(payload, "status")
+	if status == "" {
+		status = string(task_repository.TaskStatusOpen)
+	}
+	if status != "all" && !task_repository.TaskStatus(status).Valid() {
+		return getFailureResponse(payload, "status must be one of open, completed, canceled or all"), nil
+	}
+
+	logger.Info().Str("conversation_id", conversationId).Str("assignee", assignee).Msg("Processing assignee")
+
+	tasks, err := c.repo.ListTasksByAssignee(assignee)
+	if err != nil {
+		logger.Error().Err(err).Str("assignee", assignee).Msg("Failed to list tasks")
+		return getFailureResponse(payload, "Internal error"), nil
+	}
+
+	if status != "all" {
+		tasks = filterTasksByStatus(tasks, task_repository.TaskStatus(status))
+	}
+
+	list := assigneeTasks{
+		Tasks:                  []*task_repository.Task{},
+		OtherConversationTasks: []*otherConversationTask{},
+	}
+	for _, task := range tasks {
+		if task.ConversationId == conversationId {
+			list.Tasks = append(list.Tasks, task)
+			continue
+		}
+		list.OtherConversationTasks = append(list.OtherConversationTasks, &otherConversationTask{
+			Name:        task.Name,
+			Status:      task.Status,
+			DueOn:       task.DueOn,
+			CompletedOn: task.CompletedOn,
+		})
+	}
+
+	taskString, err := json.Marshal(list)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal tasks")
+	}
+
+	return AgentResponse{
+		MessageVersion: "1.0",
+		Response: AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(taskString),
+					},
+				},
+			},
+		},
+	}, nil
+}
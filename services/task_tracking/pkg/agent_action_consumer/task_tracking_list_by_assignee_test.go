@@ -0,0 +1,165 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+)
+
+// fakeTaskRepository embeds the interface so only the methods a test uses need implementing.
+type fakeTaskRepository struct {
+	task_repository.TaskRepository
+	tasks   []*task_repository.Task
+	updates []task_repository.TaskUpdate
+}
+
+func (r *fakeTaskRepository) ListTasksByAssignee(assignee string) ([]*task_repository.Task, error) {
+	tasks := []*task_repository.Task{}
+	for _, task := range r.tasks {
+		if task.Assignee == assignee {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *fakeTaskRepository) GetTask(id string) (*task_repository.Task, error) {
+	for _, task := range r.tasks {
+		if task.Id == id {
+			copied := *task
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("task not found with ID: %s", id)
+}
+
+func (r *fakeTaskRepository) SetTaskStatus(id string, status task_repository.TaskStatus, completedOn string) (*task_repository.Task, error) {
+	for _, task := range r.tasks {
+		if task.Id == id {
+			task.Status = status
+			task.CompletedOn = ""
+			if status == task_repository.TaskStatusCompleted {
+				task.CompletedOn = completedOn
+			}
+			copied := *task
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("task not found with ID: %s", id)
+}
+
+// UpdateTask records the update and returns the task unchanged for both before and after.
+func (r *fakeTaskRepository) UpdateTask(id string, update task_repository.TaskUpdate) (*task_repository.Task, *task_repository.Task, error) {
+	r.updates = append(r.updates, update)
+	task, err := r.GetTask(id)
+	return task, task, err
+}
+
+// agentRequest builds a request for the function with the parameters.
+func agentRequest(t *testing.T, function string, parameters map[string]string) AgentRequest {
+	t.Helper()
+
+	payload := map[string]any{"actionGroup": "TaskTracking", "function": function}
+	params := []map[string]string{}
+	for name, value := range parameters {
+		params = append(params, map[string]string{"name": name, "type": "string", "value": value})
+	}
+	payload["parameters"] = params
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request AgentRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestHandleTaskTrackingListByAssignee(t *testing.T) {
+	repo := &fakeTaskRepository{tasks: []*task_repository.Task{
+		{Id: "1", ConversationId: "+15555550100_+15555550101", Name: "Buy breakfast", Description: "Bagels", Source: "Joe: I'll get bagels", Status: task_repository.TaskStatusOpen, Assignee: "+15555550100", DueOn: "2025-08-23"},
+		{Id: "2", ConversationId: "+15555550100_+15555550199", Name: "Call the vet", Description: "About Rex", Source: "Sam: can you call the vet?", Status: task_repository.TaskStatusOpen, Assignee: "+15555550100"},
+		{Id: "3", ConversationId: "+15555550100_+15555550101", Name: "Pack tents", Status: task_repository.TaskStatusCompleted, Assignee: "+15555550100"},
+		{Id: "4", ConversationId: "+15555550100_+15555550101", Name: "Bring chairs", Status: task_repository.TaskStatusOpen, Assignee: "+15555550100"},
+		{Id: "5", ConversationId: "+15555550100_+15555550101", Name: "Buy ice", Status: task_repository.TaskStatusOpen, Assignee: "+15555550101"},
+	}}
+	consumer := NewConsumer(nil, 0, repo)
+
+	tests := []struct {
+		name       string
+		assignee   string
+		wantState  string
+		wantTasks  []string
+		wantOthers []otherConversationTask
+	}{
+		{
+			name:       "participant",
+			assignee:   "(555) 555-0100",
+			wantState:  "REPROMPT",
+			wantTasks:  []string{"1", "4"},
+			wantOthers: []otherConversationTask{{Name: "Call the vet", Status: task_repository.TaskStatusOpen}},
+		},
+		{
+			name:      "not a participant",
+			assignee:  "+15555550199",
+			wantState: "FAILURE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := agentRequest(t, "task_tracking_list_by_assignee", map[string]string{
+				"conversation_phone_numbers": "[+15555550100,+15555550101]",
+				"assignee":                   tt.assignee,
+			})
+
+			response, err := consumer.HandleRequest(context.Background(), request)
+			if err != nil {
+				t.Fatalf("HandleRequest() error = %v", err)
+			}
+			if response.Response.FunctionResponse.ResponseState != tt.wantState {
+				t.Fatalf("response state = %s, want %s", response.Response.FunctionResponse.ResponseState, tt.wantState)
+			}
+			if tt.wantState != "REPROMPT" {
+				return
+			}
+
+			body := response.Response.FunctionResponse.ResponseBody.ContentType.Body
+			var list struct {
+				Tasks                  []*task_repository.Task `json:"tasks"`
+				OtherConversationTasks []map[string]any        `json:"other_conversation_tasks"`
+			}
+			if err := json.Unmarshal([]byte(body), &list); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", body, err)
+			}
+
+			taskIds := []string{}
+			for _, task := range list.Tasks {
+				taskIds = append(taskIds, task.Id)
+			}
+			if !slices.Equal(taskIds, tt.wantTasks) {
+				t.Errorf("tasks = %v, want %v", taskIds, tt.wantTasks)
+			}
+
+			if len(list.OtherConversationTasks) != len(tt.wantOthers) {
+				t.Fatalf("other conversation tasks = %v, want %v", list.OtherConversationTasks, tt.wantOthers)
+			}
+			for i, other := range list.OtherConversationTasks {
+				if other["name"] != tt.wantOthers[i].Name || other["status"] != string(tt.wantOthers[i].Status) {
+					t.Errorf("other conversation task = %v, want %+v", other, tt.wantOthers[i])
+				}
+				for _, field := range []string{"id", "conversation_id", "description", "source"} {
+					if _, ok := other[field]; ok {
+						t.Errorf("other conversation task has %s: %v", field, other)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+)
+
+func TestHandleTaskTrackingStatus(t *testing.T) {
+	const conversationPhoneNumbers = "[+15555550100,+15555550101]"
+
+	tests := []struct {
+		name            string
+		function        string
+		taskId          string
+		wantState       string
+		wantStatus      task_repository.TaskStatus
+		wantCompletedOn string
+	}{
+		{
+			name:            "complete an open task",
+			function:        "task_tracking_complete",
+			taskId:          "open",
+			wantState:       "REPROMPT",
+			wantStatus:      task_repository.TaskStatusCompleted,
+			wantCompletedOn: "2025-08-23",
+		},
+		{
+			name:            "complete a completed task again",
+			function:        "task_tracking_complete",
+			taskId:          "completed",
+			wantState:       "REPROMPT",
+			wantStatus:      task_repository.TaskStatusCompleted,
+			wantCompletedOn: "2025-08-23",
+		},
+		{
+			name:       "complete a canceled task",
+			function:   "task_tracking_complete",
+			taskId:     "canceled",
+			wantState:  "FAILURE",
+			wantStatus: task_repository.TaskStatusCanceled,
+		},
+		{
+			name:       "cancel an open task",
+			function:   "task_tracking_cancel",
+			taskId:     "open",
+			wantState:  "REPROMPT",
+			wantStatus: task_repository.TaskStatusCanceled,
+		},
+		{
+			name:       "cancel a canceled task again",
+			function:   "task_tracking_cancel",
+			taskId:     "canceled",
+			wantState:  "REPROMPT",
+			wantStatus: task_repository.TaskStatusCanceled,
+		},
+		{
+			name:            "cancel a completed task",
+			function:        "task_tracking_cancel",
+			taskId:          "completed",
+			wantState:       "FAILURE",
+			wantStatus:      task_repository.TaskStatusCompleted,
+			wantCompletedOn: "2025-08-20",
+		},
+		{
+			name:       "complete another conversation's task",
+			function:   "task_tracking_complete",
+			taskId:     "elsewhere",
+			wantState:  "FAILURE",
+			wantStatus: task_repository.TaskStatusOpen,
+		},
+		{
+			name:       "cancel another conversation's task",
+			function:   "task_tracking_cancel",
+			taskId:     "elsewhere",
+			wantState:  "FAILURE",
+			wantStatus: task_repository.TaskStatusOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTaskRepository{tasks: []*task_repository.Task{
+				{Id: "open", ConversationId: "+15555550100_+15555550101", Name: "Buy ice", Status: task_repository.TaskStatusOpen},
+				{Id: "completed", ConversationId: "+15555550100_+15555550101", Name: "Pack tents", Status: task_repository.TaskStatusCompleted, CompletedOn: "2025-08-20"},
+				{Id: "canceled", ConversationId: "+15555550100_+15555550101", Name: "Rent a canoe", Status: task_repository.TaskStatusCanceled},
+				{Id: "elsewhere", ConversationId: "+15555550100_+15555550199", Name: "Call the vet", Status: task_repository.TaskStatusOpen},
+			}}
+			consumer := NewConsumer(nil, 0, repo)
+
+			request := agentRequest(t, tt.function, map[string]string{
+				"conversation_phone_numbers": conversationPhoneNumbers,
+				"task_id":                    tt.taskId,
+				"completed_on":               "2025-08-23",
+			})
+			response, err := consumer.HandleRequest(context.Background(), request)
+			if err != nil {
+				t.Fatalf("HandleRequest() error = %v", err)
+			}
+			if state := response.Response.FunctionResponse.ResponseState; state != tt.wantState {
+				t.Errorf("response state = %s, want %s: %s", state, tt.wantState, response.Response.FunctionResponse.ResponseBody.ContentType.Body)
+			}
+
+			task, err := repo.GetTask(tt.taskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Status != tt.wantStatus || task.CompletedOn != tt.wantCompletedOn {
+				t.Errorf("task is %s on %q, want %s on %q", task.Status, task.CompletedOn, tt.wantStatus, tt.wantCompletedOn)
+			}
+		})
+	}
+}
+
+func TestFilterTasksByStatus(t *testing.T) {
+	tasks := []*task_repository.Task{
+		{Id: "1", Status: task_repository.TaskStatusOpen},
+		{Id: "2", Status: task_repository.TaskStatusCompleted},
+		{Id: "3", Status: task_repository.TaskStatusOpen},
+		{Id: "4", Status: task_repository.TaskStatusCanceled},
+	}
+
+	tests := []struct {
+		status task_repository.TaskStatus
+		want   []string
+	}{
+		{status: task_repository.TaskStatusOpen, want: []string{"1", "3"}},
+		{status: task_repository.TaskStatusCompleted, want: []string{"2"}},
+		{status: task_repository.TaskStatusCanceled, want: []string{"4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			ids := []string{}
+			for _, task := range filterTasksByStatus(tasks, tt.status) {
+				ids = append(ids, task.Id)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("filterTasksByStatus(%s) = %v, want %v", tt.status, ids, tt.want)
+			}
+		})
+	}
+
+	// An empty list is still a list, so it marshals as [] for the agent.
+	data, err := json.Marshal(filterTasksByStatus(nil, task_repository.TaskStatusOpen))
+	if err != nil || string(data) != "[]" {
+		t.Errorf("filterTasksByStatus(nil) marshals to %s, %v, want []", data, err)
+	}
+}
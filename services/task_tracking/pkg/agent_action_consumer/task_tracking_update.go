@@ -0,0 +1,94 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+	"github.com/rs/zerolog"
+)
+
+type TaskTrackingUpdateResponse struct {
+	Message string                `json:"message"`
+	Before  *task_repository.Task `json:"before"`
+	After   *task_repository.Task `json:"after"`
+}
+
+func (c *Consumer) handleTaskTrackingUpdate(ctx context.Context, payload AgentRequest) (AgentResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	logger.Info().Interface("payload", payload).Msg("handleTaskTrackingUpdate")
+
+	task, err := c.getConversationTask(ctx, payload)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	// Only the parameters that were passed are changed.
+	update := task_repository.TaskUpdate{}
+	if name, ok := lookupParameter(payload, "name"); ok {
+		if name == "" {
+			return getFailureResponse(payload, "name can't be empty"), nil
+		}
+		update.Name = &name
+	}
+	if description, ok := lookupParameter(payload, "description"); ok {
+		update.Description = &description
+	}
+	if source, ok := lookupParameter(payload, "source"); ok {
+		update.Source = &source
+	}
+
+	if assignee, ok := lookupParameter(payload, "assignee"); ok {
+		if assignee != "" {
+			assignee, err = conversationParticipant(task.ConversationId, assignee)
+			if err != nil {
+				return getFailureResponse(payload, err.Error()), nil
+			}
+		}
+		update.Assignee = &assignee
+	}
+
+	if due, ok := lookupParameter(payload, "due"); ok {
+		dueOn := ""
+		if due != "" {
+			dueOn, due, err = c.getDueDate(ctx, payload, task.ConversationId)
+			if err != nil {
+				return getFailureResponse(payload, err.Error()), nil
+			}
+		}
+		update.DueOn = &dueOn
+		update.DuePhrase = &due
+	}
+
+	before, after, err := c.repo.UpdateTask(task.Id, update)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	response := TaskTrackingUpdateResponse{
+		Message: "Task updated successfully",
+		Before:  before,
+		After:   after,
+	}
+	responseJson, err := json.Marshal(response)
+	if err != nil {
+		return getFailureResponse(payload, err.Error()), nil
+	}
+
+	return AgentResponse{
+		MessageVersion: "1.0",
+		Response: AgentResponseResponse{
+			ActionGroup: payload.ActionGroup,
+			Function:    payload.Function,
+			FunctionResponse: AgentResponseResponseFunctionResponse{
+				ResponseState: "REPROMPT",
+				ResponseBody: AgentResponseResponseFunctionResponseResponseBody{
+					ContentType: AgentResponseResponseFunctionResponseResponseBodyContentType{
+						Body: string(responseJson),
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,105 @@
+package agent_action_consumer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/due_date"
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
+)
+
+func TestHandleTaskTrackingUpdate(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantState  string
+		want       *task_repository.TaskUpdate // nil when nothing is updated
+	}{
+		{
+			name:       "only what's passed is changed",
+			parameters: map[string]string{"name": "Buy two bags of ice"},
+			wantState:  "REPROMPT",
+			want:       &task_repository.TaskUpdate{Name: ptr("Buy two bags of ice")},
+		},
+		{
+			name:       "empty clears",
+			parameters: map[string]string{"description": "", "assignee": "", "due": ""},
+			wantState:  "REPROMPT",
+			want:       &task_repository.TaskUpdate{Description: ptr(""), Assignee: ptr(""), DueOn: ptr(""), DuePhrase: ptr("")},
+		},
+		{
+			name:       "nothing passed",
+			parameters: map[string]string{},
+			wantState:  "REPROMPT",
+			want:       &task_repository.TaskUpdate{},
+		},
+		{
+			name:       "name can't be cleared",
+			parameters: map[string]string{"name": ""},
+			wantState:  "FAILURE",
+		},
+		{
+			name:       "assignee in the conversation",
+			parameters: map[string]string{"assignee": "(555) 555-0101"},
+			wantState:  "REPROMPT",
+			want:       &task_repository.TaskUpdate{Assignee: ptr("+15555550101")},
+		},
+		{
+			name:       "assignee outside the conversation",
+			parameters: map[string]string{"assignee": "+15555550199"},
+			wantState:  "FAILURE",
+		},
+		{
+			name:       "due date",
+			parameters: map[string]string{"due": " 2025-09-01 "},
+			wantState:  "REPROMPT",
+			want:       &task_repository.TaskUpdate{DueOn: ptr("2025-09-01"), DuePhrase: ptr("2025-09-01")},
+		},
+		{
+			name:       "another conversation's task",
+			parameters: map[string]string{"task_id": "elsewhere", "name": "Call the vet today"},
+			wantState:  "FAILURE",
+		},
+		{
+			name:       "without the conversation",
+			parameters: map[string]string{"conversation_phone_numbers": "", "name": "Buy two bags of ice"},
+			wantState:  "FAILURE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeTaskRepository{tasks: []*task_repository.Task{
+				{Id: "ice", ConversationId: "+15555550100_+15555550101", Name: "Buy ice", Description: "Two bags", Assignee: "+15555550100", DueOn: "2025-08-23"},
+				{Id: "elsewhere", ConversationId: "+15555550100_+15555550199", Name: "Call the vet"},
+			}}
+			consumer := NewConsumer(due_date.NewClock(time.UTC), 0, repo)
+
+			parameters := map[string]string{"conversation_phone_numbers": "[+15555550100,+15555550101]", "task_id": "ice"}
+			for name, value := range tt.parameters {
+				parameters[name] = value
+			}
+			response, err := consumer.HandleRequest(context.Background(), agentRequest(t, "task_tracking_update", parameters))
+			if err != nil {
+				t.Fatalf("HandleRequest() error = %v", err)
+			}
+			if state := response.Response.FunctionResponse.ResponseState; state != tt.wantState {
+				t.Errorf("response state = %s, want %s: %s", state, tt.wantState, response.Response.FunctionResponse.ResponseBody.ContentType.Body)
+			}
+
+			if tt.want == nil {
+				if len(repo.updates) != 0 {
+					t.Errorf("updates = %+v, want none", repo.updates)
+				}
+				return
+			}
+			if len(repo.updates) != 1 || !reflect.DeepEqual(repo.updates[0], *tt.want) {
+				t.Errorf("updates = %+v, want %+v", repo.updates, *tt.want)
+			}
+		})
+	}
+}
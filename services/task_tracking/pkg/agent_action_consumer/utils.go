@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/anthonywittig/text-agent/services/task_tracking/pkg/task_repository"
 	"github.com/rs/zerolog"
 	"github.com/ttacon/libphonenumber"
 )
@@ -35,6 +36,53 @@ func getConversationId(ctx context.Context, payload AgentRequest) (string, error
 	return conversationId, nil
 }
 
+// getConversationTask returns the `task_id` task if it belongs to the conversation. Tasks from other conversations
+// are reported as not found so their IDs can't be probed.
+func (c *Consumer) getConversationTask(ctx context.Context, payload AgentRequest) (*task_repository.Task, error) {
+	conversationId, err := getConversationId(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	taskId := getParameter(payload, "task_id")
+	task, err := c.repo.GetTask(taskId)
+	if err != nil {
+		return nil, err
+	}
+	if task.ConversationId != conversationId {
+		return nil, fmt.Errorf("task not found with ID: %s", taskId)
+	}
+
+	return task, nil
+}
+
+// getAssignee returns the `assignee` parameter as an E.164 phone number, or empty if it wasn't passed. Tasks can only
+// be assigned to someone in the conversation.
+func getAssignee(payload AgentRequest, conversationId string) (string, error) {
+	assignee := getParameter(payload, "assignee")
+	if assignee == "" {
+		return "", nil
+	}
+
+	return conversationParticipant(conversationId, assignee)
+}
+
+func conversationParticipant(conversationId, phoneNumber string) (string, error) {
+	number, err := libphonenumber.Parse(phoneNumber, "US")
+	if err != nil {
+		return "", errors.New("failed to parse phone number " + phoneNumber)
+	}
+	e164PhoneNumber := libphonenumber.Format(number, libphonenumber.E164)
+
+	for _, participant := range strings.Split(conversationId, "_") {
+		if participant == e164PhoneNumber {
+			return e164PhoneNumber, nil
+		}
+	}
+
+	return "", errors.New(e164PhoneNumber + " isn't a participant of the conversation")
+}
+
 func getFailureResponse(payload AgentRequest, message string) AgentResponse {
 	return AgentResponse{
 		MessageVersion: "1.0",
@@ -54,10 +102,16 @@ func getFailureResponse(payload AgentRequest, message string) AgentResponse {
 }
 
 func getParameter(payload AgentRequest, name string) string {
+	value, _ := lookupParameter(payload, name)
+	return value
+}
+
+// lookupParameter tells a parameter that wasn't passed apart from one that was passed empty.
+func lookupParameter(payload AgentRequest, name string) (string, bool) {
 	for _, param := range payload.Parameters {
 		if param.Name == name {
-			return param.Value
+			return param.Value, true
 		}
 	}
-	return ""
+	return "", false
 }
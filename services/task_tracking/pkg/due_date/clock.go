@@ -0,0 +1,29 @@
+package due_date
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Clock anchors due dates to when the agent says the source message was sent, or else now, in the default timezone.
+// The task tracking Lambda uses it since it can't see the conversation's messages; the messaging worker, which runs the
+// action group when the agent returns control, passes an AnchorSource that can.
+type Clock struct {
+	location *time.Location
+}
+
+func NewClock(location *time.Location) AnchorSource {
+	return &Clock{location: location}
+}
+
+// Anchor fails for a message ID without its sent time, rather than quietly working from now.
+func (c *Clock) Anchor(ctx context.Context, conversationId string, source Source) (time.Time, error) {
+	if !source.SentAt.IsZero() {
+		return source.SentAt.In(c.location), nil
+	}
+	if source.MessageId != "" {
+		return time.Time{}, errors.New("source_message_id can't be looked up here; pass source_sent_at, the message's sent_at_utc, along with it")
+	}
+	return time.Now().In(c.location), nil
+}
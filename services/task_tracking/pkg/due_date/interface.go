@@ -0,0 +1,36 @@
+package due_date
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnchorSource works out the time relative due dates are resolved against. It's passed in by whoever runs the
+// consumer, since the messages and the conversation's timezone belong to the messaging service.
+type AnchorSource interface {
+	// Anchor is when the source message was sent, in the timezone of the conversation. Without a source it's now.
+	Anchor(ctx context.Context, conversationId string, source Source) (time.Time, error)
+}
+
+// Source is the message a due date was mentioned in, as far as the agent told us.
+type Source struct {
+	MessageId string
+	// SentAt is when the message was sent, as the agent saw it; it's zero if the agent didn't pass it.
+	SentAt time.Time
+}
+
+// ParseLocation parses the `DEFAULT_TIMEZONE` setting, an IANA name like `America/Denver`; it's UTC if empty. It's the
+// timezone of conversations where no one has set theirs.
+func ParseLocation(value string) (*time.Location, error) {
+	if value == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", value, err)
+	}
+
+	return location, nil
+}
@@ -0,0 +1,204 @@
+package due_date
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnresolved means the phrase doesn't contain a date we understand.
+var ErrUnresolved = errors.New("couldn't work out a date from the phrase")
+
+var (
+	isoPattern      = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	numericPattern  = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?\b`)
+	monthDayPattern = regexp.MustCompile(`\b(` + monthNames + `)\b\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`)
+	dayMonthPattern = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?(` + monthNames + `)\b(?:,?\s+(\d{4}))?`)
+	inPattern       = regexp.MustCompile(`\bin\s+(a|an|one|two|three|four|five|six|seven|\d+)\s+(day|week|month)s?\b`)
+	weekdayPattern  = regexp.MustCompile(`\b(next\s+|this\s+)?(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)\b`)
+	beforePattern   = regexp.MustCompile(`^\s*(?:due\s+)?before\b`)
+	eodPattern      = regexp.MustCompile(`\beod\b`)
+	// contextPattern matches the end of the text before a date that's only a date in context (e.g. "on wed", "by may
+	// 2", "the 8/23 campout"), as opposed to an ordinary word ("sat", "may") or a fraction ("1/2 gallon").
+	contextPattern = regexp.MustCompile(`\b(?:by|on|before|until|till|due|the|this|next|of|from|after)\s+$`)
+)
+
+// monthNames are the months and their abbreviations, and nothing else, so "decide" and "junk" aren't months.
+const monthNames = `january|jan|february|feb|march|mar|april|apr|may|june|jun|july|jul|august|aug|september|sept|sep|` +
+	`october|oct|november|nov|december|dec`
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sept": time.September, "sep": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var counts = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7}
+
+// Resolve turns a due date, either an ISO date (2025-08-23) or a phrase like "by Friday" or "before the 8/23
+// campout", into a date (YYYY-MM-DD). Relative phrases are resolved against the anchor, which should be when the
+// phrase was said, in the timezone of whoever said it.
+//
+// Dates without a year are the next one on or after the anchor, weekdays are the soonest one on or after the anchor
+// ("next Friday" is the one a week after that), and "before ..." is the day before the date it names. Weekday
+// abbreviations, "may" and numeric dates followed by more words only count after a word like "by", "on" or "the".
+func Resolve(phrase string, anchor time.Time) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(phrase))
+	today := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, anchor.Location())
+
+	date, ok := resolveDate(normalized, today)
+	if !ok {
+		return "", ErrUnresolved
+	}
+
+	if beforePattern.MatchString(normalized) {
+		date = date.AddDate(0, 0, -1)
+	}
+
+	return date.Format(time.DateOnly), nil
+}
+
+func resolveDate(phrase string, today time.Time) (time.Time, bool) {
+	if m := isoPattern.FindStringSubmatch(phrase); m != nil {
+		return date(atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3]), today)
+	}
+
+	// A numeric date followed by more words has to be in context, so "1/2 gallon" isn't January 2nd.
+	if m := findInContext(numericPattern, phrase, func(m []string, end int) bool {
+		return m[3] == "" && end < len(phrase)
+	}); m != nil {
+		return dateWithOptionalYear(m[3], time.Month(atoi(m[1])), atoi(m[2]), today)
+	}
+
+	// "may" is more often a word than a month.
+	if m := findInContext(monthDayPattern, phrase, func(m []string, end int) bool {
+		return m[1] == "may" && m[3] == ""
+	}); m != nil {
+		return dateWithOptionalYear(m[3], months[m[1]], atoi(m[2]), today)
+	}
+
+	if m := findInContext(dayMonthPattern, phrase, func(m []string, end int) bool {
+		return m[2] == "may" && m[3] == ""
+	}); m != nil {
+		return dateWithOptionalYear(m[3], months[m[2]], atoi(m[1]), today)
+	}
+
+	if m := inPattern.FindStringSubmatch(phrase); m != nil {
+		n, ok := counts[m[1]]
+		if !ok {
+			n = atoi(m[1])
+		}
+		switch m[2] {
+		case "day":
+			return today.AddDate(0, 0, n), true
+		case "week":
+			return today.AddDate(0, 0, 7*n), true
+		case "month":
+			return today.AddDate(0, n, 0), true
+		}
+	}
+
+	switch {
+	case strings.Contains(phrase, "day after tomorrow"):
+		return today.AddDate(0, 0, 2), true
+	case strings.Contains(phrase, "tomorrow"):
+		return today.AddDate(0, 0, 1), true
+	case strings.Contains(phrase, "today"), strings.Contains(phrase, "tonight"), strings.Contains(phrase, "end of day"), eodPattern.MatchString(phrase):
+		return today, true
+	case strings.Contains(phrase, "end of the month"), strings.Contains(phrase, "end of month"):
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+	case strings.Contains(phrase, "end of the week"), strings.Contains(phrase, "end of week"):
+		return nextWeekday(today, time.Friday), true
+	case strings.Contains(phrase, "next week"):
+		return today.AddDate(0, 0, 7), true
+	case strings.Contains(phrase, "next month"):
+		return today.AddDate(0, 1, 0), true
+	}
+
+	// Abbreviations like "sat" and "wed" are words too, so they have to be in context.
+	if m := findInContext(weekdayPattern, phrase, func(m []string, end int) bool {
+		return m[1] == "" && len(m[2]) < len("monday")
+	}); m != nil {
+		date := nextWeekday(today, weekdays[m[2]])
+		if strings.TrimSpace(m[1]) == "next" {
+			date = date.AddDate(0, 0, 7)
+		}
+		return date, true
+	}
+
+	return time.Time{}, false
+}
+
+// findInContext returns the submatches of the first match of the pattern, skipping those that needContext says have
+// to be in context but aren't (see contextPattern).
+func findInContext(pattern *regexp.Regexp, phrase string, needContext func(m []string, end int) bool) []string {
+	for _, loc := range pattern.FindAllStringSubmatchIndex(phrase, -1) {
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = phrase[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		if needContext(m, loc[1]) && !contextPattern.MatchString(phrase[:loc[0]]) {
+			continue
+		}
+		return m
+	}
+	return nil
+}
+
+// nextWeekday is the soonest day on or after today that's the weekday.
+func nextWeekday(today time.Time, weekday time.Weekday) time.Time {
+	return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7)
+}
+
+func dateWithOptionalYear(year string, month time.Month, day int, today time.Time) (time.Time, bool) {
+	if year != "" {
+		y := atoi(year)
+		if y < 100 {
+			y += 2000
+		}
+		return date(y, month, day, today)
+	}
+
+	d, ok := date(today.Year(), month, day, today)
+	if ok && d.Before(today) {
+		return date(today.Year()+1, month, day, today)
+	}
+	return d, ok
+}
+
+// date rejects days that don't exist (e.g. 2/30) instead of letting time.Date roll them over.
+func date(year int, month time.Month, day int, today time.Time) (time.Time, bool) {
+	d := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	if d.Year() != year || d.Month() != month || d.Day() != day {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
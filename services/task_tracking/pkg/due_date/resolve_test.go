@@ -0,0 +1,84 @@
+package due_date
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Friday, August 22nd 2025.
+	friday := time.Date(2025, 8, 22, 9, 0, 0, 0, time.UTC)
+	december := time.Date(2025, 12, 20, 9, 0, 0, 0, time.UTC)
+	// Still Thursday the 21st in Denver.
+	utcFridayDenverThursday := time.Date(2025, 8, 22, 3, 0, 0, 0, time.UTC).In(denver)
+
+	tests := []struct {
+		phrase string
+		anchor time.Time
+		want   string // empty for ErrUnresolved
+	}{
+		{phrase: "2025-09-01", anchor: friday, want: "2025-09-01"},
+		{phrase: "by Friday", anchor: friday, want: "2025-08-22"},
+		{phrase: "next Friday", anchor: friday, want: "2025-08-29"},
+		{phrase: "by Saturday", anchor: friday, want: "2025-08-23"},
+		{phrase: "by Friday", anchor: utcFridayDenverThursday, want: "2025-08-22"},
+		{phrase: "today", anchor: utcFridayDenverThursday, want: "2025-08-21"},
+		{phrase: "tomorrow", anchor: friday, want: "2025-08-23"},
+		{phrase: "before the 8/23 campout", anchor: friday, want: "2025-08-22"},
+		{phrase: "8/23", anchor: friday, want: "2025-08-23"},
+		{phrase: "1/5", anchor: december, want: "2026-01-05"},
+		{phrase: "by jan 5", anchor: december, want: "2026-01-05"},
+		{phrase: "12/25/2025", anchor: december, want: "2025-12-25"},
+		{phrase: "in 2 weeks", anchor: friday, want: "2025-09-05"},
+		{phrase: "in a month", anchor: friday, want: "2025-09-22"},
+		{phrase: "end of the month", anchor: friday, want: "2025-08-31"},
+		{phrase: "the 3rd of september", anchor: friday, want: "2025-09-03"},
+		{phrase: "2/30", anchor: friday},
+		{phrase: "2025-02-30", anchor: friday},
+
+		// Abbreviations, "may" and fractions are only dates in context.
+		{phrase: "by sat", anchor: friday, want: "2025-08-23"},
+		{phrase: "on wed", anchor: friday, want: "2025-08-27"},
+		{phrase: "next sun", anchor: friday, want: "2025-08-31"},
+		{phrase: "by may 2", anchor: friday, want: "2026-05-02"},
+		{phrase: "may 2, 2026", anchor: friday, want: "2026-05-02"},
+		{phrase: "sat", anchor: friday},
+		{phrase: "sun", anchor: friday},
+		{phrase: "wed", anchor: friday},
+		{phrase: "may 2", anchor: friday},
+		{phrase: "1/2 gallon", anchor: friday},
+		{phrase: "whenever", anchor: friday},
+
+		// Only real month names and abbreviations are months, and "eod" has to be a word.
+		{phrase: "by january 5", anchor: december, want: "2026-01-05"},
+		{phrase: "by sept. 3", anchor: friday, want: "2025-09-03"},
+		{phrase: "the 3rd of sep", anchor: friday, want: "2025-09-03"},
+		{phrase: "decide 2 things by friday", anchor: friday, want: "2025-08-22"},
+		{phrase: "eod", anchor: friday, want: "2025-08-22"},
+		{phrase: "marathon 5", anchor: friday},
+		{phrase: "junk 3", anchor: friday},
+		{phrase: "2 decks", anchor: friday},
+		{phrase: "the geodes", anchor: friday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phrase, func(t *testing.T) {
+			got, err := Resolve(tt.phrase, tt.anchor)
+			if tt.want == "" {
+				if !errors.Is(err, ErrUnresolved) {
+					t.Errorf("Resolve(%q) = %q, %v, want ErrUnresolved", tt.phrase, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve(%q) = %q, %v, want %q", tt.phrase, got, err, tt.want)
+			}
+		})
+	}
+}
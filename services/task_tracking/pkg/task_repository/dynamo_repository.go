@@ -2,7 +2,10 @@ package task_repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,9 +15,26 @@ import (
 	"github.com/google/uuid"
 )
 
+// dynamoClient is the part of the DynamoDB client the repository uses.
+type dynamoClient interface {
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// maxIdempotencyAttempts bounds how often a create lets go of a key whose task was deleted and tries again.
+const maxIdempotencyAttempts = 3
+
+// errIdempotencyKeyReleased means the key belonged to a deleted task and was let go, so the create can be tried again.
+var errIdempotencyKeyReleased = errors.New("idempotency key released")
+
 type DynamoRepository struct {
-	db        *dynamodb.Client
-	tableName string
+	db                   dynamoClient
+	tableName            string
+	idempotencyTableName string
 }
 
 func New(ctx context.Context) (TaskRepository, error) {
@@ -25,19 +45,14 @@ func New(ctx context.Context) (TaskRepository, error) {
 
 	db := dynamodb.NewFromConfig(cfg)
 	return &DynamoRepository{
-		db:        db,
-		tableName: "text-agent-task-tracking",
+		db:                   db,
+		tableName:            "text-agent-task-tracking",
+		idempotencyTableName: "text-agent-task-idempotency",
 	}, nil
 }
 
-func (r *DynamoRepository) CreateTask(conversationId, name, description, source string) (*Task, error) {
-	task := &Task{
-		Id:             uuid.NewString(),
-		ConversationId: conversationId,
-		Name:           name,
-		Description:    description,
-		Source:         source,
-	}
+func (r *DynamoRepository) CreateTask(draft *Task) (*Task, error) {
+	task := newTask(draft)
 
 	av, err := attributevalue.MarshalMap(task)
 	if err != nil {
@@ -60,7 +75,152 @@ func (r *DynamoRepository) CreateTask(conversationId, name, description, source
 	return task, nil
 }
 
+func (r *DynamoRepository) CreateTaskIdempotent(draft *Task, idempotencyKey string, window time.Duration) (*Task, bool, error) {
+	for attempt := 0; attempt < maxIdempotencyAttempts; attempt++ {
+		task, deduplicated, err := r.createTaskIdempotent(draft, idempotencyKey, window)
+		if !errors.Is(err, errIdempotencyKeyReleased) {
+			return task, deduplicated, err
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to create task: idempotency key %s kept being taken", idempotencyKey)
+}
+
+// The task and its idempotency key are written in one transaction; the key's condition fails the whole thing if the
+// key was used within the window. Keys are kept a while past the window for the table's TTL to clean up.
+func (r *DynamoRepository) createTaskIdempotent(draft *Task, idempotencyKey string, window time.Duration) (*Task, bool, error) {
+	now := time.Now()
+	task := newTask(draft)
+
+	av, err := attributevalue.MarshalMap(task)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	_, err = r.db.TransactWriteItems(context.Background(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: aws.String(r.idempotencyTableName),
+					Item: map[string]types.AttributeValue{
+						"idempotency_key": &types.AttributeValueMemberS{Value: idempotencyKey},
+						"task_id":         &types.AttributeValueMemberS{Value: task.Id},
+						"created_at":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.UnixMilli())},
+						"expires_at":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Add(2*window).Unix())},
+					},
+					ConditionExpression: aws.String("attribute_not_exists(idempotency_key) OR created_at < :cutoff"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":cutoff": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Add(-window).UnixMilli())},
+					},
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(r.tableName),
+					Item:      av,
+				},
+			},
+		},
+	})
+	if err != nil {
+		originalTaskId, ok := duplicateTaskId(err)
+		if !ok {
+			return nil, false, fmt.Errorf("failed to write items to DynamoDB: %w", err)
+		}
+
+		original, err := r.findTask(originalTaskId)
+		if err != nil {
+			return nil, false, err
+		}
+		if original != nil {
+			return original, true, nil
+		}
+
+		// The original was deleted since; there's nothing to return, so let the key go and create the task after all.
+		if err := r.deleteIdempotencyKey(idempotencyKey, originalTaskId); err != nil {
+			return nil, false, err
+		}
+		return nil, false, errIdempotencyKeyReleased
+	}
+
+	task, err = r.GetTask(task.Id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get task from DynamoDB: %w", err)
+	}
+
+	return task, false, nil
+}
+
+// newTask is the draft as a new open task; the draft itself is left alone.
+func newTask(draft *Task) *Task {
+	task := *draft
+	task.Id = uuid.NewString()
+	task.Status = TaskStatusOpen
+	task.CompletedOn = ""
+	task.StatusChangedAt = 0
+	return &task
+}
+
+// duplicateTaskId returns the ID of the task the idempotency key belongs to if the transaction failed because the
+// key is taken.
+func duplicateTaskId(err error) (string, bool) {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) || len(canceled.CancellationReasons) == 0 {
+		return "", false
+	}
+
+	reason := canceled.CancellationReasons[0]
+	if reason.Code == nil || *reason.Code != "ConditionalCheckFailed" {
+		return "", false
+	}
+
+	taskId, ok := reason.Item["task_id"].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", false
+	}
+
+	return taskId.Value, true
+}
+
+func (r *DynamoRepository) deleteIdempotencyKey(idempotencyKey, taskId string) error {
+	_, err := r.db.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.idempotencyTableName),
+		Key: map[string]types.AttributeValue{
+			"idempotency_key": &types.AttributeValueMemberS{Value: idempotencyKey},
+		},
+		ConditionExpression: aws.String("task_id = :taskId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":taskId": &types.AttributeValueMemberS{Value: taskId},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			// Someone else already took the key over.
+			return nil
+		}
+		return fmt.Errorf("failed to delete item from DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
 func (r *DynamoRepository) GetTask(id string) (*Task, error) {
+	task, err := r.findTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task == nil {
+		return nil, fmt.Errorf("task not found with ID: %s", id)
+	}
+
+	return task, nil
+}
+
+// findTask returns nil if there's no task with the ID.
+func (r *DynamoRepository) findTask(id string) (*Task, error) {
 	result, err := r.db.GetItem(context.Background(), &dynamodb.GetItemInput{
 		TableName: aws.String(r.tableName),
 		Key: map[string]types.AttributeValue{
@@ -72,7 +232,7 @@ func (r *DynamoRepository) GetTask(id string) (*Task, error) {
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("task not found with ID: %s", id)
+		return nil, nil
 	}
 
 	var task Task
@@ -81,7 +241,158 @@ func (r *DynamoRepository) GetTask(id string) (*Task, error) {
 		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
 	}
 
-	return &task, nil
+	return task.withDefaults(), nil
+}
+
+func (r *DynamoRepository) UpdateTask(id string, update TaskUpdate) (*Task, *Task, error) {
+	sets := []string{}
+	removes := []string{}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"name", update.Name},
+		{"description", update.Description},
+		{"source", update.Source},
+		{"assignee", update.Assignee},
+		{"due_on", update.DueOn},
+		{"due_phrase", update.DuePhrase},
+	} {
+		if field.value == nil {
+			continue
+		}
+		names["#"+field.name] = field.name
+		// These are omitted when empty (and an index key can't be empty), so clearing them removes the attribute.
+		if *field.value == "" && field.name != "name" && field.name != "source" {
+			removes = append(removes, "#"+field.name)
+			continue
+		}
+		sets = append(sets, "#"+field.name+" = :"+field.name)
+		values[":"+field.name] = &types.AttributeValueMemberS{Value: *field.value}
+	}
+
+	// Tasks stored before statuses existed don't have one, which would keep them out of StatusDueOnIndex.
+	if update.DueOn != nil && *update.DueOn != "" {
+		names["#status"] = "status"
+		sets = append(sets, "#status = if_not_exists(#status, :open)")
+		values[":open"] = &types.AttributeValueMemberS{Value: string(TaskStatusOpen)}
+	}
+
+	if len(names) == 0 {
+		task, err := r.GetTask(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		return task, task, nil
+	}
+
+	updateExpression := ""
+	if len(sets) > 0 {
+		updateExpression += "SET " + strings.Join(sets, ", ")
+	}
+	if len(removes) > 0 {
+		updateExpression += " REMOVE " + strings.Join(removes, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:         aws.String(strings.TrimSpace(updateExpression)),
+		ConditionExpression:      aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: names,
+		ReturnValues:             types.ReturnValueAllOld,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+
+	result, err := r.db.UpdateItem(context.Background(), input)
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, nil, fmt.Errorf("task not found with ID: %s", id)
+		}
+		return nil, nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	// The old item is exactly what this write changed, which a separate read wouldn't guarantee.
+	var before Task
+	err = attributevalue.UnmarshalMap(result.Attributes, &before)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	before.withDefaults()
+
+	after := before
+	if update.Name != nil {
+		after.Name = *update.Name
+	}
+	if update.Description != nil {
+		after.Description = *update.Description
+	}
+	if update.Source != nil {
+		after.Source = *update.Source
+	}
+	if update.Assignee != nil {
+		after.Assignee = *update.Assignee
+	}
+	if update.DueOn != nil {
+		after.DueOn = *update.DueOn
+	}
+	if update.DuePhrase != nil {
+		after.DuePhrase = *update.DuePhrase
+	}
+
+	return &before, &after, nil
+}
+
+func (r *DynamoRepository) SetTaskStatus(id string, status TaskStatus, completedOn string) (*Task, error) {
+	if !status.Valid() {
+		return nil, fmt.Errorf("invalid task status: %s", status)
+	}
+
+	updateExpression := "SET #status = :status, status_changed_at = :now REMOVE completed_on"
+	values := map[string]types.AttributeValue{
+		":status": &types.AttributeValueMemberS{Value: string(status)},
+		":now":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().UnixMilli())},
+	}
+	if status == TaskStatusCompleted {
+		updateExpression = "SET #status = :status, status_changed_at = :now, completed_on = :completedOn"
+		values[":completedOn"] = &types.AttributeValueMemberS{Value: completedOn}
+	}
+
+	result, err := r.db.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String(updateExpression),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, fmt.Errorf("task not found with ID: %s", id)
+		}
+		return nil, fmt.Errorf("failed to update item in DynamoDB: %w", err)
+	}
+
+	var task Task
+	err = attributevalue.UnmarshalMap(result.Attributes, &task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	return task.withDefaults(), nil
 }
 
 func (r *DynamoRepository) DeleteTask(id string) error {
@@ -123,5 +434,80 @@ func (r *DynamoRepository) ListTasksByConversation(conversationID string) ([]*Ta
 		return nil, fmt.Errorf("failed to unmarshal tasks: %w", err)
 	}
 
+	for _, task := range tasks {
+		task.withDefaults()
+	}
+
 	return tasks, nil
 }
+
+func (r *DynamoRepository) ListTasksByAssignee(assignee string) ([]*Task, error) {
+	tasks := []*Task{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Query(context.Background(), &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			IndexName:              aws.String("AssigneeIndex"),
+			KeyConditionExpression: aws.String("assignee = :assignee"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":assignee": &types.AttributeValueMemberS{Value: assignee},
+			},
+			ExclusiveStartKey: lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+		}
+
+		var page []*Task
+		err = attributevalue.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tasks: %w", err)
+		}
+		for _, task := range page {
+			tasks = append(tasks, task.withDefaults())
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return tasks, nil
+		}
+		lastEvaluatedKey = result.LastEvaluatedKey
+	}
+}
+
+func (r *DynamoRepository) ListOpenTasksDueBetween(dueFrom, dueTo string) ([]*Task, error) {
+	tasks := []*Task{}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	for {
+		result, err := r.db.Query(context.Background(), &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			IndexName:              aws.String("StatusDueOnIndex"),
+			KeyConditionExpression: aws.String("#status = :status AND due_on BETWEEN :dueFrom AND :dueTo"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":status":  &types.AttributeValueMemberS{Value: string(TaskStatusOpen)},
+				":dueFrom": &types.AttributeValueMemberS{Value: dueFrom},
+				":dueTo":   &types.AttributeValueMemberS{Value: dueTo},
+			},
+			ExclusiveStartKey: lastEvaluatedKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query items from DynamoDB: %w", err)
+		}
+
+		var page []*Task
+		err = attributevalue.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tasks: %w", err)
+		}
+		for _, task := range page {
+			tasks = append(tasks, task.withDefaults())
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return tasks, nil
+		}
+		lastEvaluatedKey = result.LastEvaluatedKey
+	}
+}
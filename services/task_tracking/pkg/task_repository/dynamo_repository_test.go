@@ -0,0 +1,149 @@
+package task_repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	taskTable        = "tasks"
+	idempotencyTable = "idempotency"
+)
+
+// fakeDynamo keeps the items the idempotent create writes and reads. It embeds the interface so only those calls need
+// implementing. With keepKeys set, deleting an idempotency key reports success without deleting it, as if another
+// create took the key straight back.
+type fakeDynamo struct {
+	dynamoClient
+	tasks    map[string]map[string]types.AttributeValue
+	keys     map[string]map[string]types.AttributeValue
+	keepKeys bool
+}
+
+func newFakeDynamo() *fakeDynamo {
+	return &fakeDynamo{
+		tasks: map[string]map[string]types.AttributeValue{},
+		keys:  map[string]map[string]types.AttributeValue{},
+	}
+}
+
+func stringValue(item map[string]types.AttributeValue, name string) string {
+	value, _ := item[name].(*types.AttributeValueMemberS)
+	if value == nil {
+		return ""
+	}
+	return value.Value
+}
+
+func numberValue(item map[string]types.AttributeValue, name string) int64 {
+	value, _ := item[name].(*types.AttributeValueMemberN)
+	if value == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(value.Value, 10, 64)
+	return n
+}
+
+// TransactWriteItems puts the idempotency key and the task, checking the key's `created_at < :cutoff` condition.
+func (f *fakeDynamo) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	keyPut, taskPut := params.TransactItems[0].Put, params.TransactItems[1].Put
+
+	key := stringValue(keyPut.Item, "idempotency_key")
+	if existing, ok := f.keys[key]; ok && numberValue(existing, "created_at") >= numberValue(keyPut.ExpressionAttributeValues, ":cutoff") {
+		return nil, &types.TransactionCanceledException{
+			Message: aws.String("Transaction cancelled"),
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("ConditionalCheckFailed"), Item: existing},
+				{Code: aws.String("None")},
+			},
+		}
+	}
+
+	f.keys[key] = keyPut.Item
+	f.tasks[stringValue(taskPut.Item, "id")] = taskPut.Item
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (f *fakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.tasks[stringValue(params.Key, "id")]}, nil
+}
+
+func (f *fakeDynamo) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	key := stringValue(params.Key, "idempotency_key")
+	existing, ok := f.keys[key]
+	if !ok || stringValue(existing, "task_id") != stringValue(params.ExpressionAttributeValues, ":taskId") {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	if !f.keepKeys {
+		delete(f.keys, key)
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestCreateTaskIdempotent(t *testing.T) {
+	const window = 10 * time.Minute
+	draft := &Task{ConversationId: "+15555550100_+15555550101", Name: "Buy ice", Source: "Joe: I'll grab ice"}
+
+	db := newFakeDynamo()
+	repo := &DynamoRepository{db: db, tableName: taskTable, idempotencyTableName: idempotencyTable}
+
+	first, deduplicated, err := repo.CreateTaskIdempotent(draft, "k1", window)
+	if err != nil || deduplicated {
+		t.Fatalf("CreateTaskIdempotent() = %v, %v, want a new task", deduplicated, err)
+	}
+
+	// The same key within the window returns the original.
+	again, deduplicated, err := repo.CreateTaskIdempotent(draft, "k1", window)
+	if err != nil || !deduplicated || again.Id != first.Id {
+		t.Errorf("CreateTaskIdempotent() = %+v, %v, %v, want %s deduplicated", again, deduplicated, err, first.Id)
+	}
+
+	// Another key creates another task.
+	other, deduplicated, err := repo.CreateTaskIdempotent(draft, "k2", window)
+	if err != nil || deduplicated || other.Id == first.Id {
+		t.Errorf("CreateTaskIdempotent() = %+v, %v, %v, want a new task", other, deduplicated, err)
+	}
+
+	// Once the window has passed, the key is free again.
+	db.keys["k1"]["created_at"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(-window-time.Minute).UnixMilli(), 10)}
+	later, deduplicated, err := repo.CreateTaskIdempotent(draft, "k1", window)
+	if err != nil || deduplicated || later.Id == first.Id {
+		t.Errorf("CreateTaskIdempotent() after the window = %+v, %v, %v, want a new task", later, deduplicated, err)
+	}
+
+	// If the original was deleted, the key is let go and a new task is created.
+	delete(db.tasks, later.Id)
+	recreated, deduplicated, err := repo.CreateTaskIdempotent(draft, "k1", window)
+	if err != nil || deduplicated || recreated.Id == later.Id {
+		t.Errorf("CreateTaskIdempotent() after the original was deleted = %+v, %v, %v, want a new task", recreated, deduplicated, err)
+	}
+	if taskId := stringValue(db.keys["k1"], "task_id"); taskId != recreated.Id {
+		t.Errorf("key belongs to %s, want %s", taskId, recreated.Id)
+	}
+}
+
+func TestCreateTaskIdempotentGivesUp(t *testing.T) {
+	db := newFakeDynamo()
+	db.keepKeys = true
+	db.keys["k1"] = map[string]types.AttributeValue{
+		"idempotency_key": &types.AttributeValueMemberS{Value: "k1"},
+		"task_id":         &types.AttributeValueMemberS{Value: "deleted"},
+		"created_at":      &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().UnixMilli(), 10)},
+	}
+	repo := &DynamoRepository{db: db, tableName: taskTable, idempotencyTableName: idempotencyTable}
+
+	_, _, err := repo.CreateTaskIdempotent(&Task{Name: "Buy ice"}, "k1", 10*time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "kept being taken") {
+		t.Errorf("CreateTaskIdempotent() error = %v, want it to give up", err)
+	}
+	if len(db.tasks) != 0 {
+		t.Errorf("tasks = %d, want 0", len(db.tasks))
+	}
+}
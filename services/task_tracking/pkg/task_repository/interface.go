@@ -1,13 +1,35 @@
 package task_repository
 
+import "time"
+
 // TaskRepository defines the interface for task storage operations
 type TaskRepository interface {
-	// CreateTask creates a new task
-	CreateTask(conversationId, name, description, source string) (*Task, error)
+	// CreateTask creates a new task from the draft; its ID and status are filled in
+	CreateTask(draft *Task) (*Task, error)
+
+	// CreateTaskIdempotent creates a new task unless one was created with the same idempotency key within the window,
+	// in which case that task is returned and deduplicated is true
+	CreateTaskIdempotent(draft *Task, idempotencyKey string, window time.Duration) (task *Task, deduplicated bool, err error)
+
+	// UpdateTask changes only the fields set in the update and returns the task from before and after the change
+	UpdateTask(id string, update TaskUpdate) (before *Task, after *Task, err error)
+
+	// SetTaskStatus moves a task to the status; completedOn (YYYY-MM-DD) is only kept for completed tasks
+	SetTaskStatus(id string, status TaskStatus, completedOn string) (*Task, error)
+
+	// GetTask retrieves a task by ID
+	GetTask(id string) (*Task, error)
 
 	// DeleteTask removes a task by ID
 	DeleteTask(id string) error
 
 	// ListTasksByConversation retrieves all tasks for a conversation
 	ListTasksByConversation(conversationID string) ([]*Task, error)
+
+	// ListTasksByAssignee retrieves all tasks assigned to the phone number, across conversations
+	ListTasksByAssignee(assignee string) ([]*Task, error)
+
+	// ListOpenTasksDueBetween retrieves the open tasks, across conversations, that are due on or between the dates
+	// (YYYY-MM-DD)
+	ListOpenTasksDueBetween(dueFrom, dueTo string) ([]*Task, error)
 }
@@ -1,10 +1,55 @@
 package task_repository
 
+// TaskStatus is where a task is in its lifecycle
+type TaskStatus string
+
+const (
+	TaskStatusOpen      TaskStatus = "open"
+	TaskStatusCompleted TaskStatus = "completed"
+	TaskStatusCanceled  TaskStatus = "canceled"
+)
+
+// Valid is true for the statuses above
+func (s TaskStatus) Valid() bool {
+	return s == TaskStatusOpen || s == TaskStatusCompleted || s == TaskStatusCanceled
+}
+
 // Task represents a single task in the tracking system
 type Task struct {
-	Id             string `json:"id" dynamodbav:"id"`
-	ConversationId string `json:"conversation_id" dynamodbav:"conversation_id"`
-	Name           string `json:"name" dynamodbav:"name"`
-	Description    string `json:"description,omitempty" dynamodbav:"description,omitempty"`
-	Source         string `json:"source" dynamodbav:"source"`
+	Id             string     `json:"id" dynamodbav:"id"`
+	ConversationId string     `json:"conversation_id" dynamodbav:"conversation_id"`
+	Name           string     `json:"name" dynamodbav:"name"`
+	Description    string     `json:"description,omitempty" dynamodbav:"description,omitempty"`
+	Source         string     `json:"source" dynamodbav:"source"`
+	Status         TaskStatus `json:"status" dynamodbav:"status"`
+	// Assignee is the E.164 phone number of the participant who owns the task, if anyone does
+	Assignee string `json:"assignee,omitempty" dynamodbav:"assignee,omitempty"`
+	// DueOn is the date (YYYY-MM-DD) the task is due, if it has one
+	DueOn string `json:"due_on,omitempty" dynamodbav:"due_on,omitempty"`
+	// DuePhrase is how the due date was put, e.g. `by Friday`, so it can be checked against DueOn
+	DuePhrase string `json:"due_phrase,omitempty" dynamodbav:"due_phrase,omitempty"`
+	// CompletedOn is the date (YYYY-MM-DD) a completed task was done
+	CompletedOn string `json:"completed_on,omitempty" dynamodbav:"completed_on,omitempty"`
+	// StatusChangedAt is a UNIX timestamp in milliseconds; it's 0 for tasks that have always been open
+	StatusChangedAt int64 `json:"status_changed_at,omitempty" dynamodbav:"status_changed_at,omitempty"`
+}
+
+// TaskUpdate holds the fields to change; nil fields are left as they are
+type TaskUpdate struct {
+	Name        *string
+	Description *string
+	Source      *string
+	// Assignee is an E.164 phone number; empty unassigns the task
+	Assignee *string
+	// DueOn is a date (YYYY-MM-DD); empty removes the due date along with its phrase
+	DueOn     *string
+	DuePhrase *string
+}
+
+// withDefaults fills in what tasks stored before the field existed don't have
+func (t *Task) withDefaults() *Task {
+	if t.Status == "" {
+		t.Status = TaskStatusOpen
+	}
+	return t
 }